@@ -0,0 +1,92 @@
+/*
+	=====================================================================
+
+** atomicFile package **
+This package writes the files of LinGo (the vocabulary, the review
+history, the translation cache, the cached tokens...) so that they are
+never left half written: the data is first written (and synced) to a
+temporary file in the same folder, which then atomically replaces the
+old file. If the application dies while writing, the old file is still
+there, untouched.
+
+    =====================================================================
+*/
+
+package atomicFile
+
+/*
+Imported packages:
+1) io --> the content of the file is written through an io.Writer.
+2) os and path/filepath --> used to work with the files.
+*/
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+/*
+Write function:
+input: the path of a file and its new content.
+output: a possible error (the old file is left as it was, then).
+*/
+
+func Write(filename string, data []byte) error {
+	return WriteWith(filename, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+/*
+WriteWith function:
+input: the path of a file, and the function that writes its new content.
+output: a possible error (the old file is left as it was, then).
+It works like Write, for the content that is encoded straight into the file (e.g with gob).
+The new file keeps the permissions of the old one (0644 if there was none).
+*/
+
+func WriteWith(filename string, write func(w io.Writer) error) error {
+	dir := filepath.Dir(filename)
+	// Every writer has a temporary file of its own, so two of them can't write in the same one.
+	temporary, err := os.CreateTemp(dir, filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// If something goes wrong, don't leave the temporary file around.
+	defer os.Remove(temporary.Name())
+
+	if err := write(temporary); err != nil {
+		temporary.Close()
+		return err
+	}
+	if err := temporary.Sync(); err != nil {
+		temporary.Close()
+		return err
+	}
+	if err := temporary.Close(); err != nil {
+		return err
+	}
+	// Temporary files are only readable by us: keep the permissions of the old file.
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.Chmod(temporary.Name(), mode); err != nil {
+		return err
+	}
+	if err := os.Rename(temporary.Name(), filename); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// syncDir makes sure that a rename inside a directory is on disk (it does nothing on systems that don't support it).
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
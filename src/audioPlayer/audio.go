@@ -18,17 +18,19 @@ package audioPlayer
 
 /* Imported packages:
 1) bytes --> needed for the manipulation of byte slices
-2) encoding/json --> we will need it since the communication with the tts api we're using happens via json
-3) fmt --> needed to log possible errors to the console
-4) io and io/util --> needed for working with files
-5) net/http --> needed to perform the http requests tot he api
-6) os --> needed for file manipulation
-7) time --> needed to deal with times
-8) beep --> needed to play the mp3
+2) context --> needed to stop a download or a sound before the end (e.g when the user moves on to another word)
+3) encoding/json --> we will need it since the communication with the tts api we're using happens via json
+4) fmt --> needed to log possible errors to the console
+5) io and io/util --> needed for working with files
+6) net/http --> needed to perform the http requests tot he api
+7) os --> needed for file manipulation
+8) time --> needed to deal with times
+9) beep --> needed to play the mp3
 
 */
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -52,15 +54,19 @@ type Response struct {
 
 /*
 downloadFile function:
-input: 2 strings: url and filePath
+input: a context (the download stops when it's cancelled) and 2 strings: url and filePath
 output: 1 (possible) error
 This function, as the name suggests, is responsible for the download of
 the mp3 file from the api.
 */
 
-func downloadFile(url, filePath string) string {
+func downloadFile(ctx context.Context, url, filePath string) string {
 	// Make the GET request
-	response, err := http.Get(url)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err.Error()
+	}
+	response, err := http.DefaultClient.Do(request)
 	if err != nil {
 		return err.Error()
 	}
@@ -100,13 +106,27 @@ using the downloadFile function.
 */
 
 func GetAudio(text string, languageId string) string {
+	return GetAudioContext(context.Background(), text, languageId)
+}
+
+/*
+GetAudioContext function:
+it works like GetAudio, but the requests to the API stop when the context is cancelled (or its deadline passes).
+*/
+
+func GetAudioContext(ctx context.Context, text string, languageId string) string {
 	url := "https://api.soundoftext.com/sounds"
 
 	// This is the data that will be sent in the request body:
 	data := []byte(fmt.Sprintf(`{"engine": "Google", "data": {"text":"%s", "voice": "%s"}}`, text, languageId))
 
 	// Make the HTTP POST request
-	response, err := http.Post(url, "application/json", bytes.NewBuffer(data))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(data))
+	if err != nil {
+		return err.Error()
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := http.DefaultClient.Do(request)
 	if err != nil {
 		return err.Error()
 	}
@@ -142,7 +162,7 @@ func GetAudio(text string, languageId string) string {
 	localFilePath := fmt.Sprintf("audio/%s.mp3", text)
 
 	// Call the downloadFile function
-	err3 := downloadFile(mp3URL, localFilePath)
+	err3 := downloadFile(ctx, mp3URL, localFilePath)
 	// some more error handling
 	if err3 != "" {
 		return err3
@@ -159,6 +179,15 @@ application. It will play the mp3 files using concurrency.
 */
 
 func PlayMP3(filePath string) string {
+	return PlayMP3Context(context.Background(), filePath)
+}
+
+/*
+PlayMP3Context function:
+it works like PlayMP3, but the sound stops when the context is cancelled (or its deadline passes).
+*/
+
+func PlayMP3Context(ctx context.Context, filePath string) string {
 	f, err := os.Open(filePath)
 	if err != nil {
 		return err.Error()
@@ -177,8 +206,14 @@ func PlayMP3(filePath string) string {
 		close(done)
 	})))
 
-	<-done
-	return ""
+	select {
+	case <-done:
+		return ""
+	case <-ctx.Done():
+		// Stop the sound that is playing.
+		speaker.Clear()
+		return ctx.Err().Error()
+	}
 }
 
 /*
@@ -0,0 +1,169 @@
+/*
+	=====================================================================
+
+** config package **
+This package is responsible for the loading of the settings of the
+application, which are stored in setup/config.json.
+Every setting is optional: if the file (or one of its fields) is missing,
+the default value is used.
+
+    =====================================================================
+*/
+
+package config
+
+/*
+Imported packages:
+1) encoding/json --> the settings are stored as json.
+2) os --> used to read the file.
+3) sync --> used to make sure the file is loaded only once.
+*/
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// Path is the location of the settings file.
+const Path = "setup/config.json"
+
+/*
+Config struct:
+it mirrors the structure of the setup/config.json file.
+*/
+
+type Config struct {
+	// Storage is where the vocabulary is stored: "json" (languages/<language>/words.json, the default)
+	// or "sqlite" (languages/<language>/vocabulary.db).
+	Storage string `json:"storage"`
+	// Tokenizers chooses how the texts of a language are split into words, overriding the
+	// default choice of LinGo (see fileReader/tokenizer.go); it is keyed by language.
+	Tokenizers map[string]Tokenizer `json:"tokenizers"`
+	// Translation chooses where the translations of a language come from (see translator/provider.go);
+	// it is keyed by language, and the "default" key applies to the languages that aren't listed.
+	Translation map[string]Translation `json:"translation"`
+	// TranslationCacheTTL is how long the translations stored in languages/<language>/translations.json are
+	// used before asking the provider again, as a duration like "720h" (the default) or "0" to never ask again.
+	TranslationCacheTTL string `json:"translationCacheTTL,omitempty"`
+	// Export chooses how fast the words are translated when the dictionary files are made (keys f and z).
+	Export Export `json:"export"`
+}
+
+/*
+Export struct:
+the settings of the export of the dictionary files: the words are translated by some workers at the same
+time, without sending too many requests to the translation providers.
+*/
+
+type Export struct {
+	// Workers is how many words are translated at the same time (4 by default).
+	Workers int `json:"workers"`
+	// RequestsPerSecond is the most requests sent to the translation providers every second (5 by default,
+	// 0 means no limit).
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	// Retries is how many more times a word is translated when the provider fails, waiting longer every
+	// time (3 by default).
+	Retries int `json:"retries"`
+}
+
+/*
+Translation struct:
+the translation providers of a language. Each list is a chain of providers: if one of them
+fails (or doesn't know the word), the next one is tried.
+*/
+
+type Translation struct {
+	// Main is used for the key 5, the translation of the sentences (s) and the dictionary files.
+	Main []TranslationProvider `json:"main"`
+	// Alternative is used for the key 9 and the alternative translation of the sentences (S).
+	Alternative []TranslationProvider `json:"alternative"`
+}
+
+/*
+TranslationProvider struct:
+the settings of a translation provider.
+*/
+
+type TranslationProvider struct {
+	// Type is one of "google" (google translate), "mymemory" (the mymemory API), "libretranslate"
+	// (a LibreTranslate server, e.g one running on your computer) or "dictionary" (local dictionary files).
+	Type string `json:"type"`
+	// URL is the address of the server (only for the "libretranslate" type), e.g "http://localhost:5000".
+	URL string `json:"url,omitempty"`
+	// APIKey is the key of the server, if it needs one (only for the "libretranslate" type).
+	APIKey string `json:"apiKey,omitempty"`
+	// Files are the dictionaries to use (only for the "dictionary" type): .tsv files, the .ifo files of StarDict
+	// dictionaries or the .index files of dictd dictionaries; if it's empty, the dictionaries in
+	// languages/<language>/dictionaries are used.
+	Files []string `json:"files,omitempty"`
+}
+
+/*
+Tokenizer struct:
+the settings of the tokenizer of a language.
+*/
+
+type Tokenizer struct {
+	// Type is one of "whitespace" (words are separated by spaces), "rune" (every character is a word),
+	// "dictionary" (words are found with a lexicon, see the segmenter package) or "server" (an external
+	// tokenization server, like the ones in the scriptioContinuaTokenization folder).
+	Type string `json:"type"`
+	// Address is the address of the server, either host:port or unix:/path/of/the/socket (only for the "server" type).
+	Address string `json:"address,omitempty"`
+	// Command is the command LinGo runs to start the server when it isn't running (only for the "server"
+	// type), e.g ["python3", "scriptioContinuaTokenization/server_japanese.py", "127.0.0.1:8080"]; if it's
+	// empty, LinGo runs the server of the language in the scriptioContinuaTokenization folder (if there's one).
+	Command []string `json:"command,omitempty"`
+	// External means that the server is started by the user: LinGo never starts (or stops) it.
+	External bool `json:"external,omitempty"`
+	// Lexicon is a list of word lists to use (only for the "dictionary" type); if it's empty, the
+	// lexicon that ships with LinGo for the language is used.
+	Lexicon []string `json:"lexicon,omitempty"`
+	// Script is the unicode script of the words of the lexicon, e.g "Han" or "Thai" (only for the
+	// "dictionary" type, when Lexicon is set).
+	Script string `json:"script,omitempty"`
+}
+
+// defaults returns the settings used when the file doesn't specify them.
+func defaults() *Config {
+	return &Config{Storage: "json", Export: Export{Workers: 4, RequestsPerSecond: 5, Retries: 3}}
+}
+
+var (
+	current *Config
+	once    sync.Once
+	loadErr error
+)
+
+/*
+Load function:
+output: the settings of the application, and a possible error.
+The file is read only the first time the function is called; if it can't
+be parsed, the default settings are returned alongside the error.
+*/
+
+func Load() (*Config, error) {
+	once.Do(func() {
+		current = defaults()
+		content, err := os.ReadFile(Path)
+		if os.IsNotExist(err) {
+			return
+		}
+		if err != nil {
+			loadErr = err
+			return
+		}
+		if err := json.Unmarshal(content, current); err != nil {
+			current = defaults()
+			loadErr = err
+		}
+	})
+	return current, loadErr
+}
+
+// Get returns the settings of the application, ignoring a possible error while loading them.
+func Get() *Config {
+	cfg, _ := Load()
+	return cfg
+}
@@ -0,0 +1,82 @@
+package dictionary
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+
+	"example.com/packages/vocabulary"
+)
+
+/*
+ceDict struct:
+the CC-CEDICT chinese-english dictionary (https://www.mdbg.net/chinese/dictionary?page=cedict).
+Every line of the file looks like "Traditional Simplified [pin1 yin1] /meaning/meaning/"; the lines
+starting with # are comments. The whole dictionary is kept in memory, indexed by both the
+traditional and the simplified form of the words.
+*/
+
+type ceDict struct {
+	name    string
+	entries map[string][]Entry
+}
+
+// cedictLine is a line of a CC-CEDICT file.
+var cedictLine = regexp.MustCompile(`^(\S+) (\S+) \[([^\]]*)\] /(.*)/\s*$`)
+
+// cedictPinyin is the pinyin written inside the meanings (e.g "CL:個|个[ge4]").
+var cedictPinyin = regexp.MustCompile(`\[([A-Za-z0-9: ,·]+)\]`)
+
+/*
+OpenCEDICT function:
+input: the path of a CC-CEDICT file (e.g cedict_ts.u8).
+output: the dictionary, and a possible error.
+*/
+
+func OpenCEDICT(path string) (Dictionary, error) {
+	d := &ceDict{name: "CC-CEDICT", entries: map[string][]Entry{}}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	// The lines with many meanings can be longer than the default buffer.
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		match := cedictLine.FindStringSubmatch(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if match == nil {
+			continue
+		}
+		traditional, simplified := match[1], match[2]
+		entry := Entry{Dictionary: d.name, Headword: simplified, Reading: PinyinToneMarks(match[3])}
+		if traditional != simplified {
+			entry.Headword += " (" + traditional + ")"
+		}
+		for _, sense := range strings.Split(match[4], "/") {
+			if sense = strings.TrimSpace(sense); sense != "" {
+				entry.Senses = append(entry.Senses, cedictPinyin.ReplaceAllStringFunc(sense, func(pinyin string) string {
+					return "[" + PinyinToneMarks(pinyin[1:len(pinyin)-1]) + "]"
+				}))
+			}
+		}
+		if len(entry.Senses) == 0 {
+			continue
+		}
+		entry.Definition = numberedSenses(entry.Senses)
+		d.entries[vocabulary.Key(simplified)] = append(d.entries[vocabulary.Key(simplified)], entry)
+		if traditional != simplified {
+			d.entries[vocabulary.Key(traditional)] = append(d.entries[vocabulary.Key(traditional)], entry)
+		}
+	}
+	return d, scanner.Err()
+}
+
+func (d *ceDict) Name() string {
+	return d.name
+}
+
+func (d *ceDict) Lookup(key string) ([]Entry, error) {
+	return d.entries[key], nil
+}
@@ -0,0 +1,125 @@
+package dictionary
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"example.com/packages/vocabulary"
+)
+
+// dictdDigits are the digits of the numbers of a dictd .index file (they are written in base 64).
+const dictdDigits = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+/*
+dictd struct:
+a dictionary in the format of the dictd server (https://github.com/cheusov/dictd). It is made of:
+- the .index file, where every line is a word, the position and the size of its definition
+  (written in base 64), separated by tabs;
+- the .dict file (possibly compressed, .dict.dz), with the definitions.
+The words whose names start with 00-database (or 00database) are the informations about the dictionary.
+*/
+
+type dictd struct {
+	name  string
+	index map[string][]dictdItem
+	data  dataFile
+}
+
+// dictdItem is a line of the .index file.
+type dictdItem struct {
+	headword string
+	offset   int64
+	size     int64
+}
+
+/*
+OpenDictd function:
+input: the path of the .index file of a dictd dictionary.
+output: the dictionary, and a possible error.
+*/
+
+func OpenDictd(path string) (Dictionary, error) {
+	base := strings.TrimSuffix(path, ".index")
+	d := &dictd{name: filepath.Base(base), index: map[string][]dictdItem{}}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var short *dictdItem
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 3 {
+			continue
+		}
+		offset, ok1 := decodeDictdNumber(fields[1])
+		size, ok2 := decodeDictdNumber(fields[2])
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("line %d: invalid position of the definition", line)
+		}
+		item := dictdItem{headword: fields[0], offset: offset, size: size}
+		if strings.HasPrefix(item.headword, "00-database") || strings.HasPrefix(item.headword, "00database") {
+			if strings.HasSuffix(item.headword, "short") {
+				short = &item
+			}
+			continue
+		}
+		key := vocabulary.Key(item.headword)
+		d.index[key] = append(d.index[key], item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	dataPath := base + ".dict"
+	if _, err := os.Stat(dataPath + ".dz"); err == nil {
+		dataPath += ".dz"
+	}
+	d.data, err = openData(dataPath)
+	if err != nil {
+		return nil, err
+	}
+	// The short description of the dictionary is its name (its first line is the name of the word, 00-database-short).
+	if short != nil {
+		if data, err := d.data.read(short.offset, short.size); err == nil {
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			if name := strings.TrimSpace(lines[len(lines)-1]); name != "" {
+				d.name = name
+			}
+		}
+	}
+	return d, nil
+}
+
+// decodeDictdNumber decodes a number of the .index file.
+func decodeDictdNumber(text string) (int64, bool) {
+	var number int64
+	for _, char := range text {
+		digit := strings.IndexRune(dictdDigits, char)
+		if digit == -1 {
+			return 0, false
+		}
+		number = number*64 + int64(digit)
+	}
+	return number, text != ""
+}
+
+func (d *dictd) Name() string {
+	return d.name
+}
+
+func (d *dictd) Lookup(key string) ([]Entry, error) {
+	var entries []Entry
+	for _, item := range d.index[key] {
+		data, err := d.data.read(item.offset, item.size)
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, Entry{Dictionary: d.name, Headword: item.headword, Definition: strings.TrimSpace(string(data))})
+	}
+	return entries, nil
+}
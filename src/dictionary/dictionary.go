@@ -0,0 +1,209 @@
+/*
+	=====================================================================
+
+** dictionary package **
+This package is responsible for the offline dictionaries: the files the
+user puts in languages/<language>/dictionaries are indexed when the language
+is first used, and then every lookup is done in memory (the definitions
+are read from disk only when we need them), without any connection.
+The supported formats are StarDict (.ifo/.idx/.dict, also compressed),
+dictd (.index/.dict, also compressed) and simple tab-separated files (.tsv),
+plus the chinese CC-CEDICT and the japanese JMdict and EDICT dictionaries,
+whose entries are split into readings, senses and tags.
+
+    =====================================================================
+*/
+
+package dictionary
+
+/*
+Imported packages:
+1) errors, fmt --> used to report the dictionaries we couldn't read.
+2) path/filepath, regexp, strings --> used to find the dictionary files and tell their formats apart.
+3) os --> used to check if the dictionaries outside the directory of the language exist.
+4) sync --> used to make sure the dictionaries of a language are loaded only once.
+5) vocabulary --> the headwords are normalized like the words of the vocabulary (see vocabulary.Key).
+*/
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"example.com/packages/vocabulary"
+)
+
+/*
+Entry struct:
+the definition of a word in a dictionary.
+*/
+
+type Entry struct {
+	Dictionary string   // The name of the dictionary
+	Headword   string   // The word, as it is written in the dictionary
+	Reading    string   // The pronunciation of the word: pinyin with tone marks, kana (empty if the dictionary doesn't say)
+	Senses     []string // The senses of the word (only for the dictionaries that separate them, like CC-CEDICT and JMdict)
+	Tags       []string // E.g "common", "HSK 1", "JLPT N5" (see LoadTags)
+	Definition string   // The full definition (it can have many lines: senses, examples exc.)
+}
+
+// numberedSenses writes the senses of a word one per line, numbered (when there's more than one).
+func numberedSenses(senses []string) string {
+	if len(senses) == 1 {
+		return senses[0]
+	}
+	lines := make([]string, len(senses))
+	for k, sense := range senses {
+		lines[k] = fmt.Sprintf("%d. %s", k+1, sense)
+	}
+	return strings.Join(lines, "\n")
+}
+
+/*
+Dictionary interface:
+a dictionary file, whose index has been loaded in memory.
+*/
+
+type Dictionary interface {
+	// Name is the name of the dictionary (e.g the bookname of a StarDict dictionary).
+	Name() string
+	// Lookup returns the entries of a key (see vocabulary.Key).
+	Lookup(key string) ([]Entry, error)
+}
+
+/*
+Library struct:
+all the dictionaries of a language.
+*/
+
+type Library struct {
+	Dictionaries []Dictionary
+	// Tags are the tags of the words (e.g the level of the HSK or JLPT exam they belong to), keyed by vocabulary.Key.
+	Tags map[string][]string
+}
+
+// Dir returns the directory of the dictionaries of a language.
+func Dir(language string) string {
+	return fmt.Sprintf("languages/%s/dictionaries", language)
+}
+
+// extraSources are the dictionaries that are outside the directory of the dictionaries of a language: the CC-CEDICT
+// file the chinese segmenter uses is also a dictionary (see segmenter/languages.go).
+var extraSources = map[string][]string{
+	"chinese": {"languages/chinese/cedict_ts.u8"},
+}
+
+var (
+	libraries   = map[string]*Library{}
+	librariesMu sync.Mutex
+)
+
+/*
+ForLanguage function:
+input: a language.
+output: the dictionaries in the directory of the language, and a possible error (if some of them
+couldn't be read: the others are in the library anyway).
+The dictionaries are loaded the first time they are requested and then kept in memory.
+*/
+
+func ForLanguage(language string) (*Library, error) {
+	librariesMu.Lock()
+	defer librariesMu.Unlock()
+	if library, ok := libraries[language]; ok {
+		return library, nil
+	}
+	files, err := filepath.Glob(filepath.Join(Dir(language), "*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range extraSources[language] {
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	library, err := Open(files...)
+	libraries[language] = library
+	return library, err
+}
+
+// dataFileName matches the names of the files of the StarDict and dictd dictionaries, other than the .ifo and .index ones.
+var dataFileName = regexp.MustCompile(`\.(idx|idx\.gz|idx\.oft|syn|syn\.oft|dict|dict\.dz)$`)
+
+/*
+Open function:
+input: the paths of some dictionary files (the .ifo file of a StarDict dictionary,
+the .index file of a dictd dictionary, a .tsv file, a CC-CEDICT file (.u8, or any file whose name
+starts with cedict), a JMdict or EDICT file (whose names start with jmdict or edict, also compressed
+with gzip), or a list of tagged words (.tags); the other files are ignored).
+output: the library made of the dictionaries, and a possible error (if some of them couldn't be read).
+*/
+
+func Open(paths ...string) (*Library, error) {
+	library := &Library{Tags: map[string][]string{}}
+	var errs []error
+	for _, path := range paths {
+		var dictionary Dictionary
+		var err error
+		name := strings.ToLower(filepath.Base(path))
+		switch {
+		case strings.HasSuffix(name, ".ifo"):
+			dictionary, err = OpenStarDict(path)
+		case strings.HasSuffix(name, ".index"):
+			dictionary, err = OpenDictd(path)
+		case strings.HasSuffix(name, ".tsv"):
+			dictionary, err = OpenTSV(path)
+		case strings.HasSuffix(name, ".tags"):
+			if err := library.LoadTags(path); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			}
+			continue
+		// The other files of the StarDict and dictd dictionaries are read together with their .ifo/.index file.
+		case dataFileName.MatchString(name):
+			continue
+		case strings.HasSuffix(name, ".u8") || strings.HasPrefix(name, "cedict"):
+			dictionary, err = OpenCEDICT(path)
+		case strings.HasPrefix(name, "jmdict"):
+			dictionary, err = OpenJMdict(path)
+		case strings.HasPrefix(name, "edict"):
+			dictionary, err = OpenEDICT(path)
+		default:
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		library.Dictionaries = append(library.Dictionaries, dictionary)
+	}
+	return library, errors.Join(errs...)
+}
+
+/*
+Lookup method:
+input: a word (as it appears in the text, or already normalized).
+output: the entries of the word in all the dictionaries of the library, and a possible error.
+*/
+
+func (l *Library) Lookup(word string) ([]Entry, error) {
+	key := vocabulary.Key(word)
+	if key == "" {
+		return nil, nil
+	}
+	var entries []Entry
+	var errs []error
+	for _, dictionary := range l.Dictionaries {
+		found, err := dictionary.Lookup(key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", dictionary.Name(), err))
+		}
+		for _, entry := range found {
+			entry.Tags = addTags(entry.Tags, l.Tags[key]...)
+			entries = append(entries, entry)
+		}
+	}
+	return entries, errors.Join(errs...)
+}
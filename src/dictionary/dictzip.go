@@ -0,0 +1,194 @@
+package dictionary
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+/*
+dataFile interface:
+the file that holds the definitions of a StarDict or dictd dictionary, from which
+we read a definition at a time (given its offset and size).
+*/
+
+type dataFile interface {
+	read(offset int64, size int64) ([]byte, error)
+}
+
+/*
+openData function:
+input: the path of a data file: either plain (.dict) or compressed (.dict.dz).
+output: the data file, and a possible error.
+A .dict.dz file is a dictzip file: a gzip file made of chunks that can be decompressed one by one,
+so that we can read a definition without decompressing the whole file. If the file is a plain gzip
+file (without the dictzip chunks), it is decompressed in memory.
+*/
+
+func openData(path string) (dataFile, error) {
+	if !strings.HasSuffix(path, ".dz") {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		return plainData{file}, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := openDictzip(file)
+	if err == nil {
+		return data, nil
+	}
+	// It isn't a dictzip file: decompress it all.
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return memoryData(content), nil
+}
+
+// plainData is an uncompressed data file.
+type plainData struct {
+	file *os.File
+}
+
+func (d plainData) read(offset int64, size int64) ([]byte, error) {
+	buffer := make([]byte, size)
+	_, err := d.file.ReadAt(buffer, offset)
+	return buffer, err
+}
+
+// memoryData is a data file we decompressed in memory.
+type memoryData []byte
+
+func (d memoryData) read(offset int64, size int64) ([]byte, error) {
+	if offset < 0 || size < 0 || offset+size > int64(len(d)) {
+		return nil, errors.New("the definition is out of the data file")
+	}
+	return d[offset : offset+size], nil
+}
+
+// dictzipData is a dictzip file.
+type dictzipData struct {
+	file        *os.File
+	chunkLength int64   // The size of a chunk, once decompressed
+	chunks      []int64 // Where every chunk starts in the file (and, at the end, where the last one ends)
+}
+
+/*
+openDictzip function:
+input: a file.
+output: the dictzip file, and an error if the file isn't a dictzip file.
+The chunks are described by the "RA" field of the gzip header.
+*/
+
+func openDictzip(file *os.File) (*dictzipData, error) {
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return nil, err
+	}
+	if header[0] != 0x1f || header[1] != 0x8b || header[2] != 8 {
+		return nil, errors.New("not a gzip file")
+	}
+	flags := header[3]
+	if flags&0x04 == 0 {
+		return nil, errors.New("not a dictzip file")
+	}
+	var extraLength uint16
+	if err := binary.Read(file, binary.LittleEndian, &extraLength); err != nil {
+		return nil, err
+	}
+	extra := make([]byte, extraLength)
+	if _, err := io.ReadFull(file, extra); err != nil {
+		return nil, err
+	}
+	data := &dictzipData{file: file}
+	var sizes []int64
+	for len(extra) >= 4 {
+		length := int(binary.LittleEndian.Uint16(extra[2:4]))
+		if 4+length > len(extra) {
+			break
+		}
+		if extra[0] == 'R' && extra[1] == 'A' && length >= 6 {
+			field := extra[4 : 4+length]
+			data.chunkLength = int64(binary.LittleEndian.Uint16(field[2:4]))
+			count := int(binary.LittleEndian.Uint16(field[4:6]))
+			for k := 0; k < count && 6+2*k+2 <= len(field); k++ {
+				sizes = append(sizes, int64(binary.LittleEndian.Uint16(field[6+2*k:])))
+			}
+		}
+		extra = extra[4+length:]
+	}
+	if data.chunkLength == 0 || len(sizes) == 0 {
+		return nil, errors.New("not a dictzip file")
+	}
+	// Skip the name, the comment and the checksum of the header, if they are there.
+	position, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	for _, flag := range []byte{0x08, 0x10} {
+		if flags&flag == 0 {
+			continue
+		}
+		for {
+			var char [1]byte
+			if _, err := file.ReadAt(char[:], position); err != nil {
+				return nil, err
+			}
+			position++
+			if char[0] == 0 {
+				break
+			}
+		}
+	}
+	if flags&0x02 != 0 {
+		position += 2
+	}
+	data.chunks = append(data.chunks, position)
+	for _, size := range sizes {
+		position += size
+		data.chunks = append(data.chunks, position)
+	}
+	return data, nil
+}
+
+func (d *dictzipData) read(offset int64, size int64) ([]byte, error) {
+	first := offset / d.chunkLength
+	last := (offset + size - 1) / d.chunkLength
+	if offset < 0 || size < 0 || last >= int64(len(d.chunks)-1) {
+		return nil, errors.New("the definition is out of the data file")
+	}
+	var content bytes.Buffer
+	for chunk := first; chunk <= last; chunk++ {
+		section := io.NewSectionReader(d.file, d.chunks[chunk], d.chunks[chunk+1]-d.chunks[chunk])
+		// Every chunk ends with a flush, so it can be decompressed on its own.
+		reader := flate.NewReader(section)
+		_, err := io.CopyN(&content, reader, d.chunkLength)
+		reader.Close()
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+	}
+	start := offset - first*d.chunkLength
+	if start+size > int64(content.Len()) {
+		return nil, errors.New("the definition is out of the data file")
+	}
+	return content.Bytes()[start : start+size], nil
+}
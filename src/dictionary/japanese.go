@@ -0,0 +1,306 @@
+package dictionary
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"example.com/packages/vocabulary"
+	"golang.org/x/text/encoding/japanese"
+)
+
+/*
+jaDict struct:
+a japanese dictionary of the JMdict project (https://www.edrdg.org/jmdict/j_jmdict.html), either in the
+xml format (JMdict, JMdict_e) or in the older text format (EDICT, EDICT2). The whole dictionary is kept
+in memory, indexed by every way of writing the words, in kanji and in kana.
+*/
+
+type jaDict struct {
+	name    string
+	entries map[string][]Entry
+}
+
+func (d *jaDict) Name() string {
+	return d.name
+}
+
+func (d *jaDict) Lookup(key string) ([]Entry, error) {
+	return d.entries[key], nil
+}
+
+// add adds an entry, that can be found with any of the given ways of writing the word.
+func (d *jaDict) add(entry Entry, writings []string) {
+	entry.Definition = numberedSenses(entry.Senses)
+	added := map[string]bool{}
+	for _, writing := range writings {
+		if key := vocabulary.Key(writing); key != "" && !added[key] {
+			added[key] = true
+			d.entries[key] = append(d.entries[key], entry)
+		}
+	}
+}
+
+// openMaybeGzip opens a file, decompressing it if its name ends with .gz.
+func openMaybeGzip(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil || !strings.HasSuffix(path, ".gz") {
+		return file, err
+	}
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{reader, file}, nil
+}
+
+/*
+jmdictEntry struct:
+an entry of the JMdict xml file: the ways of writing the word in kanji (k_ele) and in kana (r_ele), each
+with its priority (how common it is), and its senses, each with its parts of speech and translations.
+*/
+
+type jmdictEntry struct {
+	Kanji []struct {
+		Text     string   `xml:"keb"`
+		Priority []string `xml:"ke_pri"`
+	} `xml:"k_ele"`
+	Readings []struct {
+		Text     string   `xml:"reb"`
+		Priority []string `xml:"re_pri"`
+	} `xml:"r_ele"`
+	Senses []struct {
+		PartOfSpeech []string `xml:"pos"`
+		Field        []string `xml:"field"`
+		Misc         []string `xml:"misc"`
+		Info         []string `xml:"s_inf"`
+		Glosses      []struct {
+			Text     string `xml:",chardata"`
+			Language string `xml:"lang,attr"`
+		} `xml:"gloss"`
+	} `xml:"sense"`
+}
+
+// jmdictEntity is the declaration of an entity in the DTD of the JMdict file (they are used for the parts of speech, e.g &n;).
+var jmdictEntity = regexp.MustCompile(`<!ENTITY\s+(\S+)\s+"`)
+
+/*
+OpenJMdict function:
+input: the path of a JMdict xml file (e.g JMdict_e, also compressed: JMdict_e.gz).
+output: the dictionary, and a possible error.
+Only the english translations are kept (all of them, if a sense has no english translation).
+The parts of speech and the other codes of the dictionary are kept short, as the entities of the file (e.g "v5k", "uk").
+*/
+
+func OpenJMdict(path string) (Dictionary, error) {
+	file, err := openMaybeGzip(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	d := &jaDict{name: "JMdict", entries: map[string][]Entry{}}
+	decoder := xml.NewDecoder(bufio.NewReader(file))
+	decoder.Entity = map[string]string{}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch token := token.(type) {
+		case xml.Directive:
+			// The entities stand for themselves.
+			for _, match := range jmdictEntity.FindAllSubmatch(token, -1) {
+				decoder.Entity[string(match[1])] = string(match[1])
+			}
+		case xml.StartElement:
+			if token.Name.Local != "entry" {
+				continue
+			}
+			var raw jmdictEntry
+			if err := decoder.DecodeElement(&raw, &token); err != nil {
+				return nil, err
+			}
+			d.addJMdict(raw)
+		}
+	}
+	return d, nil
+}
+
+// addJMdict adds an entry of the JMdict file.
+func (d *jaDict) addJMdict(raw jmdictEntry) {
+	entry := Entry{Dictionary: d.name}
+	var writings, readings, priorities []string
+	for _, kanji := range raw.Kanji {
+		writings = append(writings, kanji.Text)
+		priorities = append(priorities, kanji.Priority...)
+	}
+	for _, reading := range raw.Readings {
+		readings = append(readings, reading.Text)
+		priorities = append(priorities, reading.Priority...)
+	}
+	if len(writings) == 0 && len(readings) == 0 {
+		return
+	}
+	if len(writings) > 0 {
+		entry.Headword = strings.Join(writings, ", ")
+		entry.Reading = strings.Join(readings, ", ")
+	} else {
+		entry.Headword = strings.Join(readings, ", ")
+	}
+	entry.Tags = priorityTags(priorities)
+	for _, sense := range raw.Senses {
+		var glosses, all []string
+		for _, gloss := range sense.Glosses {
+			all = append(all, gloss.Text)
+			if gloss.Language == "" || gloss.Language == "eng" {
+				glosses = append(glosses, gloss.Text)
+			}
+		}
+		if len(glosses) == 0 {
+			glosses = all
+		}
+		if len(glosses) == 0 {
+			continue
+		}
+		text := strings.Join(glosses, "; ")
+		if codes := append(append(append([]string{}, sense.PartOfSpeech...), sense.Field...), sense.Misc...); len(codes) > 0 {
+			text = "(" + strings.Join(codes, ", ") + ") " + text
+		}
+		if len(sense.Info) > 0 {
+			text += " [" + strings.Join(sense.Info, "; ") + "]"
+		}
+		entry.Senses = append(entry.Senses, text)
+	}
+	if len(entry.Senses) == 0 {
+		return
+	}
+	d.add(entry, append(writings, readings...))
+}
+
+/*
+priorityTags function:
+input: the priority codes of the ways of writing a word in JMdict.
+output: the tags of the word: "common" for the words in the lists of common words (news1, ichi1, spec1, spec2, gai1)
+and "top <n>" for the position of the word among the most frequent ones (the nf01-nf48 codes, in blocks of 500 words).
+*/
+
+func priorityTags(priorities []string) []string {
+	var tags []string
+	best := 0
+	for _, priority := range priorities {
+		switch {
+		case priority == "news1" || priority == "ichi1" || priority == "spec1" || priority == "spec2" || priority == "gai1":
+			tags = addTags(tags, "common")
+		case strings.HasPrefix(priority, "nf"):
+			if block, err := strconv.Atoi(priority[2:]); err == nil && block > 0 && (best == 0 || block < best) {
+				best = block
+			}
+		}
+	}
+	if best != 0 {
+		tags = append(tags, fmt.Sprintf("top %d", best*500))
+	}
+	return tags
+}
+
+var (
+	// edictMarker is a marker inside the words of EDICT, like (P), (iK) or a restriction of a reading to some writings.
+	edictMarker = regexp.MustCompile(`\([^)]*\)`)
+	// edictSenseNumber is the number at the beginning of a new sense, e.g "(2)" (possibly after the parts of speech).
+	edictSenseNumber = regexp.MustCompile(`\(\d+\)\s*`)
+)
+
+/*
+OpenEDICT function:
+input: the path of an EDICT or EDICT2 file (in UTF-8 or in EUC-JP, the encoding of the original files,
+also compressed with gzip).
+output: the dictionary, and a possible error.
+Every line of the file looks like "KANJI;KANJI [KANA;KANA] /(pos) (1) meaning; meaning/(2) meaning/(P)/EntL1234X/"
+(the words written only in kana don't have the part in brackets); (P) marks the common words.
+*/
+
+func OpenEDICT(path string) (Dictionary, error) {
+	file, err := openMaybeGzip(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(content) {
+		if content, err = japanese.EUCJP.NewDecoder().Bytes(content); err != nil {
+			return nil, err
+		}
+	}
+	d := &jaDict{name: "EDICT", entries: map[string][]Entry{}}
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		d.addEDICT(strings.TrimPrefix(string(line), "\ufeff"))
+	}
+	if len(d.entries) == 0 {
+		return nil, errors.New("no entry found")
+	}
+	return d, nil
+}
+
+// addEDICT adds a line of the EDICT file.
+func (d *jaDict) addEDICT(line string) {
+	head, body, ok := strings.Cut(line, " /")
+	// The first line of the file is a description of the file itself.
+	if !ok || strings.HasPrefix(head, "　？？？") || strings.HasPrefix(head, "#") {
+		return
+	}
+	var writings, readings []string
+	kanji, kana, hasReading := strings.Cut(head, " [")
+	for _, writing := range strings.Split(kanji, ";") {
+		if writing = strings.TrimSpace(edictMarker.ReplaceAllString(writing, "")); writing != "" {
+			writings = append(writings, writing)
+		}
+	}
+	if hasReading {
+		for _, reading := range strings.Split(strings.TrimSuffix(strings.TrimSpace(kana), "]"), ";") {
+			if reading = strings.TrimSpace(edictMarker.ReplaceAllString(reading, "")); reading != "" {
+				readings = append(readings, reading)
+			}
+		}
+	}
+	if len(writings) == 0 {
+		return
+	}
+	entry := Entry{Dictionary: d.name, Headword: strings.Join(writings, ", "), Reading: strings.Join(readings, ", ")}
+	for _, field := range strings.Split(strings.TrimSuffix(strings.TrimSpace(body), "/"), "/") {
+		field = strings.TrimSpace(field)
+		switch {
+		case field == "":
+		case field == "(P)":
+			entry.Tags = addTags(entry.Tags, "common")
+		case strings.HasPrefix(field, "EntL"):
+		// A number starts a new sense (the fields without a number belong to the previous sense).
+		case edictSenseNumber.MatchString(field) || len(entry.Senses) == 0:
+			entry.Senses = append(entry.Senses, strings.TrimSpace(edictSenseNumber.ReplaceAllString(field, "")))
+		default:
+			entry.Senses[len(entry.Senses)-1] += "; " + field
+		}
+	}
+	if len(entry.Senses) == 0 {
+		return
+	}
+	d.add(entry, append(writings, readings...))
+}
@@ -0,0 +1,78 @@
+package dictionary
+
+import (
+	"strings"
+	"unicode"
+)
+
+// toneMarks are the vowels of pinyin with the marks of the tones 1 to 4.
+var toneMarks = map[rune][4]rune{
+	'a': {'ā', 'á', 'ǎ', 'à'},
+	'e': {'ē', 'é', 'ě', 'è'},
+	'i': {'ī', 'í', 'ǐ', 'ì'},
+	'o': {'ō', 'ó', 'ǒ', 'ò'},
+	'u': {'ū', 'ú', 'ǔ', 'ù'},
+	'ü': {'ǖ', 'ǘ', 'ǚ', 'ǜ'},
+	'A': {'Ā', 'Á', 'Ǎ', 'À'},
+	'E': {'Ē', 'É', 'Ě', 'È'},
+	'O': {'Ō', 'Ó', 'Ǒ', 'Ò'},
+}
+
+/*
+PinyinToneMarks function:
+input: pinyin written with the numbers of the tones, as CC-CEDICT does (e.g "ni3 hao3", "lu:4", "Bei3 jing1").
+output: the same pinyin with the tone marks (e.g "nǐ hǎo", "lǜ", "Běi jīng").
+The mark goes on the a or the e if the syllable has one, on the o of ou, and otherwise on the last vowel;
+the neutral tone (5) has no mark.
+*/
+
+func PinyinToneMarks(pinyin string) string {
+	syllables := strings.Split(pinyin, " ")
+	for k, syllable := range syllables {
+		syllables[k] = syllableToneMark(syllable)
+	}
+	return strings.Join(syllables, " ")
+}
+
+// syllableToneMark puts the tone mark on a syllable of pinyin (see PinyinToneMarks).
+func syllableToneMark(syllable string) string {
+	syllable = strings.NewReplacer("u:", "ü", "U:", "Ü", "v", "ü").Replace(syllable)
+	runes := []rune(syllable)
+	if len(runes) < 2 || runes[len(runes)-1] < '1' || runes[len(runes)-1] > '5' {
+		return syllable
+	}
+	tone := int(runes[len(runes)-1] - '0')
+	runes = runes[:len(runes)-1]
+	if tone == 5 {
+		return string(runes)
+	}
+	position := -1
+	for k, char := range runes {
+		if lower := unicode.ToLower(char); lower == 'a' || lower == 'e' {
+			position = k
+			break
+		}
+		if unicode.ToLower(char) == 'o' && k+1 < len(runes) && runes[k+1] == 'u' {
+			position = k
+			break
+		}
+	}
+	if position == -1 {
+		for k := len(runes) - 1; k >= 0; k-- {
+			if strings.ContainsRune("aeiouüAEIOUÜ", runes[k]) {
+				position = k
+				break
+			}
+		}
+	}
+	if position == -1 {
+		return string(runes)
+	}
+	vowel := runes[position]
+	if marks, ok := toneMarks[vowel]; ok {
+		runes[position] = marks[tone-1]
+	} else if marks, ok := toneMarks[unicode.ToLower(vowel)]; ok {
+		runes[position] = unicode.ToUpper(marks[tone-1])
+	}
+	return string(runes)
+}
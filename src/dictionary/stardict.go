@@ -0,0 +1,258 @@
+package dictionary
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"example.com/packages/vocabulary"
+)
+
+/*
+starDict struct:
+a StarDict dictionary. It is made of:
+- the .ifo file, which describes the dictionary (its name, the number of words exc.);
+- the .idx file (possibly compressed, .idx.gz), the sorted list of the words, each with the
+  position and the size of its definition in the .dict file;
+- the .dict file (possibly compressed, .dict.dz), with the definitions;
+- optionally the .syn file, with the synonyms of the words (other ways of writing them).
+The .idx and .syn files are loaded in memory, the definitions are read when we need them.
+*/
+
+type starDict struct {
+	name             string
+	sameTypeSequence string
+	index            map[string][]starDictItem
+	data             dataFile
+}
+
+// starDictItem is a word of the .idx file.
+type starDictItem struct {
+	headword string
+	offset   int64
+	size     int64
+}
+
+/*
+OpenStarDict function:
+input: the path of the .ifo file of a StarDict dictionary.
+output: the dictionary, and a possible error.
+*/
+
+func OpenStarDict(path string) (Dictionary, error) {
+	base := strings.TrimSuffix(path, ".ifo")
+	info, err := readIfo(path)
+	if err != nil {
+		return nil, err
+	}
+	d := &starDict{name: info["bookname"], sameTypeSequence: info["sametypesequence"], index: map[string][]starDictItem{}}
+	if d.name == "" {
+		d.name = filepath.Base(base)
+	}
+	offsetBits := 32
+	if info["idxoffsetbits"] == "64" {
+		offsetBits = 64
+	}
+
+	content, err := readMaybeCompressed(base+".idx", base+".idx.gz")
+	if err != nil {
+		return nil, err
+	}
+	var items []starDictItem
+	for len(content) > 0 {
+		end := bytes.IndexByte(content, 0)
+		if end == -1 || len(content) < end+1+offsetBits/8+4 {
+			return nil, errors.New("the .idx file is truncated")
+		}
+		item := starDictItem{headword: string(content[:end])}
+		content = content[end+1:]
+		if offsetBits == 64 {
+			item.offset = int64(binary.BigEndian.Uint64(content))
+			content = content[8:]
+		} else {
+			item.offset = int64(binary.BigEndian.Uint32(content))
+			content = content[4:]
+		}
+		item.size = int64(binary.BigEndian.Uint32(content))
+		content = content[4:]
+		items = append(items, item)
+		d.add(item.headword, item)
+	}
+
+	// The synonyms point to the words of the .idx file (by their position in the file).
+	if synonyms, err := os.ReadFile(base + ".syn"); err == nil {
+		for len(synonyms) > 0 {
+			end := bytes.IndexByte(synonyms, 0)
+			if end == -1 || len(synonyms) < end+5 {
+				break
+			}
+			position := binary.BigEndian.Uint32(synonyms[end+1:])
+			if int(position) < len(items) {
+				d.add(string(synonyms[:end]), items[position])
+			}
+			synonyms = synonyms[end+5:]
+		}
+	}
+
+	dataPath := base + ".dict"
+	if _, err := os.Stat(dataPath + ".dz"); err == nil {
+		dataPath += ".dz"
+	}
+	d.data, err = openData(dataPath)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// add adds a word to the index (once, even if it is also a synonym of itself).
+func (d *starDict) add(word string, item starDictItem) {
+	key := vocabulary.Key(word)
+	for _, other := range d.index[key] {
+		if other.offset == item.offset && other.size == item.size {
+			return
+		}
+	}
+	d.index[key] = append(d.index[key], item)
+}
+
+func (d *starDict) Name() string {
+	return d.name
+}
+
+func (d *starDict) Lookup(key string) ([]Entry, error) {
+	var entries []Entry
+	for _, item := range d.index[key] {
+		data, err := d.data.read(item.offset, item.size)
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, Entry{Dictionary: d.name, Headword: item.headword, Definition: d.definition(data)})
+	}
+	return entries, nil
+}
+
+/*
+definition method:
+input: the data of a word in the .dict file.
+output: the definition, as plain text.
+The data is a sequence of fields, each with a type: lowercase types are text ending with a 0 byte,
+uppercase types are binary data (images, sounds exc.) preceded by their size. If the dictionary has
+a "sametypesequence", the types aren't written in the data, and the last field has no ending (or size).
+*/
+
+func (d *starDict) definition(data []byte) string {
+	var fields []string
+	types := d.sameTypeSequence
+	for k := 0; len(data) > 0; k++ {
+		var fieldType byte
+		if types != "" {
+			if k >= len(types) {
+				break
+			}
+			fieldType = types[k]
+		} else {
+			fieldType = data[0]
+			data = data[1:]
+		}
+		last := types != "" && k == len(types)-1
+		var field []byte
+		if fieldType >= 'a' && fieldType <= 'z' {
+			end := bytes.IndexByte(data, 0)
+			if last || end == -1 {
+				field, data = data, nil
+			} else {
+				field, data = data[:end], data[end+1:]
+			}
+		} else {
+			size := len(data)
+			if !last && len(data) >= 4 {
+				size = int(binary.BigEndian.Uint32(data))
+				data = data[4:]
+			}
+			if size > len(data) {
+				size = len(data)
+			}
+			data = data[size:]
+			continue
+		}
+		switch fieldType {
+		case 'm', 'l', 'y', 'k':
+			fields = append(fields, strings.TrimSpace(string(field)))
+		case 't':
+			fields = append(fields, "/"+strings.TrimSpace(string(field))+"/")
+		case 'h', 'g', 'x':
+			fields = append(fields, stripMarkup(string(field)))
+		}
+	}
+	return strings.Join(fields, "\n")
+}
+
+var (
+	// lineBreaks are the tags that end a line.
+	lineBreaks = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|tr|h[1-6]|def|ex|blockquote)>`)
+	// tags are all the other tags.
+	tags = regexp.MustCompile(`<[^>]*>`)
+	// blankLines are the empty lines we remove.
+	blankLines = regexp.MustCompile(`\n\s*\n+`)
+)
+
+// stripMarkup converts a definition written in html (or in xdxf, or pango markup) to plain text.
+func stripMarkup(text string) string {
+	text = lineBreaks.ReplaceAllString(text, "\n")
+	text = tags.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	text = blankLines.ReplaceAllString(text, "\n")
+	return strings.TrimSpace(text)
+}
+
+// readIfo reads the settings of a .ifo file.
+func readIfo(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() || !strings.HasPrefix(strings.TrimPrefix(scanner.Text(), "\ufeff"), "StarDict's dict ifo file") {
+		return nil, errors.New("not a StarDict .ifo file")
+	}
+	info := map[string]string{}
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok {
+			info[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	if offsetBits := info["idxoffsetbits"]; offsetBits != "" && offsetBits != "32" && offsetBits != "64" {
+		return nil, fmt.Errorf("unsupported idxoffsetbits %q", offsetBits)
+	}
+	return info, scanner.Err()
+}
+
+// readMaybeCompressed reads a file, or its compressed version if the file doesn't exist.
+func readMaybeCompressed(path string, compressedPath string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err == nil || !os.IsNotExist(err) {
+		return content, err
+	}
+	file, err := os.Open(compressedPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(reader)
+}
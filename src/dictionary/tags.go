@@ -0,0 +1,68 @@
+package dictionary
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"example.com/packages/vocabulary"
+)
+
+/*
+LoadTags method:
+input: the path of a .tags file.
+output: a possible error.
+A .tags file is a list of words (one per line) that get the name of the file as tag: e.g the words in
+"HSK 1.tags" are tagged "HSK 1". A line can also give its own tags after a tab, separated by commas
+(e.g "猫<TAB>JLPT N5, animal"). The lines starting with # are comments.
+The tags are added to the entries of the words in all the dictionaries of the library.
+*/
+
+func (l *Library) LoadTags(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimPrefix(scanner.Text(), "\ufeff")
+		word, tags, ok := strings.Cut(line, "\t")
+		key := vocabulary.Key(word)
+		if key == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		if !ok {
+			l.Tags[key] = addTags(l.Tags[key], name)
+			continue
+		}
+		for _, tag := range strings.Split(tags, ",") {
+			l.Tags[key] = addTags(l.Tags[key], tag)
+		}
+	}
+	return scanner.Err()
+}
+
+// addTags adds some tags to a list of tags, skipping the empty ones and the ones that are already there.
+func addTags(list []string, tags ...string) []string {
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		found := false
+		for _, other := range list {
+			if other == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			// Copy the list, so that we never change the tags of another entry.
+			list = append(list[:len(list):len(list)], tag)
+		}
+	}
+	return list
+}
@@ -0,0 +1,73 @@
+package dictionary
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"example.com/packages/vocabulary"
+)
+
+/*
+tsv struct:
+a dictionary written as a simple text file: every line is a word and its definition separated
+by a tab, and the lines starting with # are comments. A definition can span many lines
+by writing \n in it, and a word can have many lines (e.g one for each sense).
+Unlike the other formats, the whole file is kept in memory.
+*/
+
+type tsv struct {
+	name    string
+	entries map[string][]Entry
+}
+
+/*
+OpenTSV function:
+input: the path of a .tsv file.
+output: the dictionary, and a possible error.
+*/
+
+func OpenTSV(path string) (Dictionary, error) {
+	d := &tsv{name: strings.TrimSuffix(filepath.Base(path), ".tsv"), entries: map[string][]Entry{}}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		word, definition, ok := strings.Cut(text, "\t")
+		if !ok || strings.HasPrefix(word, "#") {
+			continue
+		}
+		key := vocabulary.Key(word)
+		definition = strings.TrimSpace(strings.ReplaceAll(definition, `\n`, "\n"))
+		if key == "" || definition == "" {
+			continue
+		}
+		// The lines of the same word are the senses of a single entry.
+		if entries := d.entries[key]; len(entries) > 0 && entries[len(entries)-1].Headword == word {
+			entries[len(entries)-1].Definition += "\n" + definition
+			continue
+		}
+		d.entries[key] = append(d.entries[key], Entry{Dictionary: d.name, Headword: word, Definition: definition})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *tsv) Name() string {
+	return d.name
+}
+
+func (d *tsv) Lookup(key string) ([]Entry, error) {
+	return d.entries[key], nil
+}
@@ -0,0 +1,115 @@
+package fileReader
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"example.com/packages/atomicFile"
+)
+
+/*
+The tokens of the texts split by a slow tokenizer (a server or a lexicon, see the Versioned interface)
+are cached on disk, in languages/<language>/tokens: every text has its own cache file, that stores the
+tokens together with what they were computed from (the content of the text, the tokenizer and its version).
+When one of these changes the tokens are computed again, so reopening a text is instant (and it doesn't
+need the server of the language) until the text is edited or the tokenizer is updated.
+*/
+
+// TokenCacheFormat is the version of the cache files: it changes when the way we store (or compute) the tokens changes.
+const TokenCacheFormat = 1
+
+// tokenCache is the content of a cache file.
+type tokenCache struct {
+	Format    int     // The TokenCacheFormat the file was written with
+	Filename  string  // The path of the text
+	Hash      string  // The sha256 hash of the content of the text
+	Tokenizer string  // The name of the tokenizer
+	Version   string  // The version of the tokenizer
+	Tokens    []Token // The tokens of the text
+}
+
+// tokenCachePath returns the path of the cache file of a text.
+func tokenCachePath(language string, filename string) string {
+	sum := sha256.Sum256([]byte(filename))
+	return fmt.Sprintf("languages/%s/tokens/%s.gob", language, hex.EncodeToString(sum[:8]))
+}
+
+/*
+TokenizeCached function:
+input: a tokenizer, the language and the path of a text, and the content of the text.
+output: the tokens of the text, and a possible error.
+If the tokenizer is slow (i.e it implements Versioned), the tokens are read from the cache when they
+are up to date, and stored in the cache otherwise. If we don't know the version of the tokenizer (e.g
+its server is down), the cached tokens of the same content and tokenizer are good enough.
+*/
+
+func TokenizeCached(tokenizer Tokenizer, language string, filename string, content string) ([]Token, error) {
+	versioned, ok := tokenizer.(Versioned)
+	if !ok {
+		return tokenizer.Tokenize(content)
+	}
+	sum := sha256.Sum256([]byte(content))
+	hash := hex.EncodeToString(sum[:])
+	version := versioned.Version()
+	path := tokenCachePath(language, filename)
+
+	if cache, err := readTokenCache(path); err == nil {
+		if cache.Format == TokenCacheFormat && cache.Filename == filename && cache.Hash == hash &&
+			cache.Tokenizer == tokenizer.Name() && (version == "" || cache.Version == version) {
+			return cache.Tokens, nil
+		}
+	}
+	tokens, err := tokenizer.Tokenize(content)
+	if err != nil {
+		return nil, err
+	}
+	// The cache only makes opening the text faster: if we can't write it, we will just tokenize the text again.
+	writeTokenCache(path, tokenCache{
+		Format:    TokenCacheFormat,
+		Filename:  filename,
+		Hash:      hash,
+		Tokenizer: tokenizer.Name(),
+		Version:   version,
+		Tokens:    tokens,
+	})
+	return tokens, nil
+}
+
+// readTokenCache reads a cache file.
+func readTokenCache(path string) (tokenCache, error) {
+	var cache tokenCache
+	file, err := os.Open(path)
+	if err != nil {
+		return cache, err
+	}
+	defer file.Close()
+	err = gob.NewDecoder(bufio.NewReader(file)).Decode(&cache)
+	return cache, err
+}
+
+/*
+writeTokenCache function:
+input: the path of a cache file and its content.
+output: a possible error.
+The cache is written in a temporary file which then replaces the old one (see atomicFile),
+so that a crash never leaves a half-written cache file behind.
+*/
+
+func writeTokenCache(path string, cache tokenCache) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return atomicFile.WriteWith(path, func(w io.Writer) error {
+		writer := bufio.NewWriter(w)
+		if err := gob.NewEncoder(writer).Encode(cache); err != nil {
+			return err
+		}
+		return writer.Flush()
+	})
+}
@@ -0,0 +1,277 @@
+package fileReader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/packages/config"
+	"example.com/packages/languageHandler"
+	"example.com/packages/translator"
+	"example.com/packages/vocabulary"
+)
+
+/*
+The dictionary files (see MakeDictionary and MakeAltDictionary) are made by a pool of workers, which translate
+some words at the same time in the background. The words we already have a translation for (in the vocabulary or
+in the translation cache) are written right away; for the others, the workers don't send more requests to the
+translation providers than config.Export allows, and a request that fails is tried again a few times, waiting
+longer every time. The words that still couldn't be translated are left out of the file and reported.
+*/
+
+// exportBackoff is how long a worker waits before translating again a word the provider failed to translate (it doubles every time).
+const exportBackoff = time.Second
+
+/*
+ExportFailure struct:
+a word that couldn't be translated while making a dictionary file, and why.
+*/
+
+type ExportFailure struct {
+	Word  string
+	Error string
+}
+
+/*
+ExportResult struct:
+what happened while making a dictionary file.
+*/
+
+type ExportResult struct {
+	Filename string          // The path of the dictionary file (empty if it wasn't written)
+	Words    int             // How many words were written in it
+	Failures []ExportFailure // The words that couldn't be translated (they aren't in the file)
+	Report   string          // The path of the file listing the failures (empty if there are none)
+}
+
+/*
+exporter struct:
+the shared state of the workers making a dictionary file.
+*/
+
+type exporter struct {
+	ctx          context.Context
+	words        *vocabulary.Store
+	language     string
+	languageId   string
+	bootLanguage string
+	retries      int
+	limiter      <-chan time.Time // A tick every time a request can be sent (nil if there's no limit)
+}
+
+// wait waits until a request can be sent to the translation providers (or the export is stopped).
+func (e *exporter) wait() error {
+	if e.limiter == nil {
+		return e.ctx.Err()
+	}
+	select {
+	case <-e.limiter:
+		return nil
+	case <-e.ctx.Done():
+		return e.ctx.Err()
+	}
+}
+
+/*
+request method:
+input: the function that sends a request, and the function that tells the errors that won't go away if we ask again.
+output: the result of the request, and a possible error.
+The request respects the limit of the export, and it is tried again (waiting longer every time) when it fails.
+*/
+
+func (e *exporter) request(send func() (string, error), permanent func(err error) bool) (string, error) {
+	backoff := exportBackoff
+	for attempt := 0; ; attempt++ {
+		if err := e.wait(); err != nil {
+			return "", err
+		}
+		result, err := send()
+		if result != "" {
+			return result, nil
+		}
+		if attempt >= e.retries || permanent(err) {
+			return "", err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-e.ctx.Done():
+			return "", e.ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+/*
+translate method:
+input: a word.
+output: its main translation, and a possible error.
+It works like LookupTranslation, but the requests to the translation provider go through request (unless the provider
+simply doesn't know the word), and the lookup isn't recorded in the vocabulary: the user didn't ask for it.
+*/
+
+func (e *exporter) translate(word string) (string, error) {
+	if translation, ok := knownTranslation(e.words, word, e.language, e.languageId, e.bootLanguage); ok {
+		return translation, nil
+	}
+	translation, err := e.request(func() (string, error) {
+		// If only the translation cache couldn't be saved, we still have the translation (see the end of exportDictionary).
+		return translator.TranslateWord(e.ctx, e.language, word, e.languageId, e.bootLanguage, false)
+	}, translator.NotFound)
+	if err != nil {
+		return "", err
+	}
+	e.words.Update(word, func(entry *vocabulary.Entry) {
+		entry.Translation = translation
+	})
+	return translation, nil
+}
+
+/*
+latinize method:
+input: a word, and the pinyin of the chinese characters (see translator.LatinizeText).
+output: its latinization, and a possible error.
+It works like LookupLatinization, but the latinizations that need a request (see translator.LatinizationOnline)
+go through request, like the translations.
+*/
+
+func (e *exporter) latinize(word string, hanziData map[string][]string) (string, error) {
+	if latinization, ok := knownLatinization(e.words, word, e.language); ok {
+		return latinization, nil
+	}
+	send := func() (string, error) {
+		return translator.LatinizeTextContext(e.ctx, word, hanziData, e.language)
+	}
+	var latinization string
+	var err error
+	if translator.LatinizationOnline(e.language) {
+		latinization, err = e.request(send, func(error) bool { return false })
+	} else {
+		latinization, err = send()
+	}
+	if err != nil {
+		return "", err
+	}
+	saveLatinization(e.words, word, latinization)
+	return latinization, nil
+}
+
+// knownTranslation returns the translation of a word we already have (see LookupTranslation), and whether there's one.
+func knownTranslation(words *vocabulary.Store, word string, language string, languageId string, bootLanguage string) (string, bool) {
+	entry, found := words.Lookup(word)
+	if found && entry.Meaning != "" {
+		return entry.Meaning, true
+	}
+	if pinned, ok := translator.Pinned(language, word, languageId, bootLanguage); ok {
+		return pinned, true
+	}
+	if found && entry.Translation != "" {
+		return entry.Translation, true
+	}
+	return translator.Cached(language, word, languageId, bootLanguage)
+}
+
+/*
+exportDictionary function:
+input: a context (cancelling it stops the export), the vocabulary, the language we're studying, the language of the
+interface, a function called every time a word is done (it can be nil; it's called by the workers, at the same time)
+and the function that makes the line of a word in the file.
+output: what happened, and a possible error (if the export was stopped, or a file couldn't be written: when only the
+translation cache couldn't be saved, the result is complete and the error comes with it).
+The file contains the words we don't know (level 1 or 2), in alphabetical order.
+*/
+
+func exportDictionary(ctx context.Context, words *vocabulary.Store, language string, bootLanguage string, progress func(done int, total int), line func(e *exporter, word string) (string, error)) (ExportResult, error) {
+	var result ExportResult
+	var list []string
+	for word, level := range words.Levels() {
+		if level == 1 || level == 2 {
+			list = append(list, word)
+		}
+	}
+	sort.Strings(list)
+
+	settings := config.Get().Export
+	e := &exporter{ctx: ctx, words: words, language: language, languageId: languageHandler.LanguageMap2[language], bootLanguage: bootLanguage, retries: settings.Retries}
+	if settings.RequestsPerSecond > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / settings.RequestsPerSecond))
+		defer ticker.Stop()
+		e.limiter = ticker.C
+	}
+	workers := max(settings.Workers, 1)
+
+	if progress != nil {
+		progress(0, len(list))
+	}
+	lines := make([]string, len(list))
+	failures := make([]error, len(list))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+	for k := 0; k < workers; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				lines[index], failures[index] = line(e, list[index])
+				mu.Lock()
+				done++
+				if progress != nil {
+					progress(done, len(list))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for index := range list {
+		select {
+		case jobs <- index:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	// The workers only stored the translations in the cache: write them on disk now.
+	cacheErr := translator.FlushCache(language)
+
+	content := "\n"
+	report := ""
+	for index, word := range list {
+		if failures[index] != nil {
+			result.Failures = append(result.Failures, ExportFailure{Word: word, Error: failures[index].Error()})
+			report += fmt.Sprintf("%s\t%s\n", word, strings.ReplaceAll(failures[index].Error(), "\n", "; "))
+			continue
+		}
+		content += lines[index] + "\n"
+		result.Words++
+	}
+	filename := fmt.Sprintf("languages/%s/dictionary.txt", language)
+	if err := os.WriteFile(filename, []byte(content), 0666); err != nil {
+		return result, err
+	}
+	result.Filename = filename
+	// The report of the failures is replaced at every export (and removed when there are none).
+	reportPath := fmt.Sprintf("languages/%s/dictionary_failures.txt", language)
+	if report == "" {
+		if err := os.Remove(reportPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return result, err
+		}
+		return result, cacheErr
+	}
+	result.Report = reportPath
+	if err := os.WriteFile(reportPath, []byte(report), 0666); err != nil {
+		return result, err
+	}
+	return result, cacheErr
+}
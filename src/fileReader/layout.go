@@ -0,0 +1,231 @@
+package fileReader
+
+import (
+	"example.com/packages/terminalSize"
+)
+
+/*
+Line struct:
+a line of a page, made of the tokens TokenList[Start:End] of the text.
+A line ends either because it is full or because the text went on a new line (in which
+case its last token is the layout token); an empty line (Start == End) is the empty line
+we display between two paragraphs.
+*/
+
+type Line struct {
+	Start int // The position of the first token of the line
+	End   int // The position after the last token of the line
+}
+
+// Page struct: a page of the text, made of the tokens TokenList[Start:End], divided in lines.
+type Page struct {
+	Start int    // The position of the first token of the page
+	End   int    // The position after the last token of the page
+	Lines []Line // The lines of the page
+}
+
+// CursorPadding is the padding on the left of the word under the cursor (see selectedItemStyle in main.go):
+// we leave room for it on every line, so that moving the cursor never makes a line overflow.
+const CursorPadding = 2
+
+/*
+DivideInPages function:
+input: The list of tokens of the text, and the width and height of the terminal.
+output: the list of pages of the text.
+
+The following function is responsible for the determination of the number of pages in which the text opened will
+be divided, by taking into account the size of the terminal, making thus the feel of the application responsive.
+The line breaks and paragraphs of the text are kept: a line ends when the next token doesn't fit in the
+width of the terminal (measured in cells, see Token.Width) or when the text went on a new line.
+*/
+
+func DivideInPages(tokens []Token, terminalWidth int, terminalHeight int) []Page {
+	// To see more info on what the 2 functions used below do, check the comments in the terminalSize.go file inside the terminalSize directory.
+	// For now just know that these 2 lines set width and linesPerPage equal to the width of a line and the preferred number of lines per page, by taking into account the size of the terminal.
+	width := terminalSize.LineWidth(terminalWidth) - CursorPadding
+	linesPerPage := terminalSize.LinesPerPage(terminalHeight)
+
+	var pages []Page
+	page := Page{}
+	for _, line := range divideInLines(tokens, width) {
+		// Don't start a page with the empty line between two paragraphs.
+		if len(page.Lines) == 0 {
+			if line.Start == line.End {
+				continue
+			}
+			page.Start = line.Start
+		}
+		page.Lines = append(page.Lines, line)
+		page.End = line.End
+		if len(page.Lines) == linesPerPage {
+			pages = append(pages, page)
+			page = Page{}
+		}
+	}
+	// We deal with the last page separately (a text always has at least one page, even if it's empty).
+	if len(page.Lines) > 0 || len(pages) == 0 {
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+/*
+divideInLines function:
+input: the list of tokens of the text and the width of a line (in cells).
+output: the lines of the text.
+Layout tokens don't take any space: a newline token ends the line it is in, and a
+paragraph token also adds an empty line after it.
+We only break a line where the text had whitespace or between two words that weren't
+separated by anything (e.g two chinese characters), so punctuation stays attached to its word.
+A token that is wider than the whole line gets a line of its own.
+*/
+
+func divideInLines(tokens []Token, width int) []Line {
+	var lines []Line
+	// start is the first token of the current line, used the number of cells it takes,
+	// and lastBreak the last position of the line where we can break it (-1 if there's none).
+	start, used, lastBreak := 0, 0, -1
+	for k, token := range tokens {
+		if token.IsLayout() {
+			lines = append(lines, Line{Start: start, End: k + 1})
+			if token.Kind == ParagraphToken {
+				lines = append(lines, Line{Start: k + 1, End: k + 1})
+			}
+			start, used, lastBreak = k+1, 0, -1
+			continue
+		}
+		if k != start && canBreakBefore(tokens, k) {
+			lastBreak = k
+		}
+		used += advance(tokens, start, k)
+		if used > width && k != start {
+			// The token doesn't fit: go on a new line from the last place where we can break the line.
+			breakAt := k
+			if lastBreak > start {
+				breakAt = lastBreak
+			}
+			lines = append(lines, Line{Start: start, End: breakAt})
+			start, lastBreak = breakAt, -1
+			used = span(tokens, start, k+1)
+		}
+	}
+	if start < len(tokens) {
+		lines = append(lines, Line{Start: start, End: len(tokens)})
+	}
+	return lines
+}
+
+// canBreakBefore checks if we can start a new line at a token.
+func canBreakBefore(tokens []Token, position int) bool {
+	token := tokens[position]
+	return token.SpaceBefore || (token.IsWord() && tokens[position-1].IsWord())
+}
+
+// advance returns how many cells a token adds to the line that starts at start (the token itself, and the space before it).
+func advance(tokens []Token, start int, position int) int {
+	token := tokens[position]
+	if token.IsLayout() {
+		return 0
+	}
+	if position != start && token.SpaceBefore {
+		return token.Width() + 1
+	}
+	return token.Width()
+}
+
+// span returns how many cells the tokens between start and end take on a line that starts at start.
+func span(tokens []Token, start int, end int) int {
+	width := 0
+	for k := start; k < end; k++ {
+		width += advance(tokens, start, k)
+	}
+	return width
+}
+
+/*
+Paginate method:
+input: the width and height of the terminal.
+It divides the text in pages for a terminal of that size, and shows the page the
+cursor is in (so that the cursor stays on the same word when the terminal is resized).
+The phrases found in the old pages are forgotten (see PagePhrases).
+*/
+
+func (t *Text) Paginate(terminalWidth int, terminalHeight int) {
+	t.PageList = DivideInPages(t.TokenList, terminalWidth, terminalHeight)
+	t.Pages = len(t.PageList)
+	t.CurrentPage = PageOf(t.PageList, t.TokenCursorPosition)
+	t.ForgetPhrases()
+}
+
+// PageOf returns the page that contains the token at a position.
+func PageOf(pages []Page, position int) int {
+	for k, page := range pages {
+		if position < page.End {
+			return k
+		}
+	}
+	return len(pages) - 1
+}
+
+/*
+VerticalMove function:
+input: the list of tokens of the text, its pages, the position of the cursor and a direction
+(1 to go down, -1 to go up).
+output: the position of the word on the previous/next line that has a word in it, as close as
+possible to the column (in cells) where the cursor is displayed, or -1 if there isn't such a line.
+*/
+
+func VerticalMove(tokens []Token, pages []Page, position int, direction int) int {
+	var lines []Line
+	for _, page := range pages {
+		lines = append(lines, page.Lines...)
+	}
+	current := -1
+	for k, line := range lines {
+		if position >= line.Start && position < line.End {
+			current = k
+			break
+		}
+	}
+	if current == -1 {
+		return -1
+	}
+	line := lines[current]
+	column := span(tokens, line.Start, position+1) - tokens[position].Width()
+	for k := current + direction; k >= 0 && k < len(lines); k += direction {
+		if target := wordAtColumn(tokens, lines[k], column); target != -1 {
+			return target
+		}
+	}
+	return -1
+}
+
+/*
+wordAtColumn function:
+input: the list of tokens of the text, a line and a column (in cells).
+output: the word of the line displayed at that column or, if there's none, the closest
+word to it (the one on the left if there's a tie); -1 if the line has no words.
+*/
+
+func wordAtColumn(tokens []Token, line Line, column int) int {
+	best, bestDistance := -1, 0
+	cell := 0
+	for k := line.Start; k < line.End; k++ {
+		cell += advance(tokens, line.Start, k)
+		if !tokens[k].IsWord() {
+			continue
+		}
+		// The token takes the cells from left (included) to cell (excluded).
+		left := cell - tokens[k].Width()
+		distance := 0
+		if column < left {
+			distance = left - column
+		} else if column >= cell {
+			distance = column - cell + 1
+		}
+		if best == -1 || distance < bestDistance {
+			best, bestDistance = k, distance
+		}
+	}
+	return best
+}
@@ -0,0 +1,121 @@
+package fileReader
+
+import "example.com/packages/vocabulary"
+
+/*
+Phrases are expressions made of many words (e.g "take off" or "a pesar de"): they are stored in the
+vocabulary like the words, under the key of the whole phrase (see PhraseKey), so they get a level,
+a translation and end up in the dictionary files like any other word.
+*/
+
+// MaxPhraseLength is the maximum number of words of a phrase we look for in the texts.
+const MaxPhraseLength = 8
+
+/*
+Span struct:
+a range of tokens (from Start to End, excluded) that makes up a phrase of the vocabulary.
+*/
+
+type Span struct {
+	Start int
+	End   int
+	Level int // The level of knowledge of the phrase
+}
+
+/*
+Selection function:
+input: the two ends of a selection (the position where it started and the position of the cursor).
+output: the range of tokens (from start to end, excluded) between them.
+*/
+
+func Selection(anchor int, cursor int) (int, int) {
+	if anchor > cursor {
+		anchor, cursor = cursor, anchor
+	}
+	return anchor, cursor + 1
+}
+
+// PhraseText returns the text of the tokens from start to end (excluded), on a single line.
+func PhraseText(tokens []Token, start int, end int) string {
+	start, end = max(start, 0), min(end, len(tokens))
+	if start >= end {
+		return ""
+	}
+	return JoinTokens(tokens[start:end])
+}
+
+// PhraseKey returns the key under which the phrase made of the tokens from start to end (excluded) is stored in the vocabulary.
+func PhraseKey(tokens []Token, start int, end int) string {
+	return vocabulary.Key(PhraseText(tokens, start, end))
+}
+
+/*
+FindPhrases function:
+input: the list of tokens of a text, the range of tokens we are interested in (e.g a page) and the vocabulary.
+output: the phrases of the vocabulary (of at least two words, with a level from 1 to 3) that start in the range.
+When phrases overlap, the one that starts first (and then the longest one) wins. A phrase never crosses
+the end of a paragraph.
+*/
+
+func FindPhrases(tokens []Token, start int, end int, words *vocabulary.Store) []Span {
+	var spans []Span
+	for position := max(start, 0); position < min(end, len(tokens)); position++ {
+		if !tokens[position].IsWord() {
+			continue
+		}
+		// The places where a phrase starting here can end: right after one of the next words.
+		var ends []int
+		for k := position; k < len(tokens) && len(ends) < MaxPhraseLength && tokens[k].Kind != ParagraphToken; k++ {
+			if tokens[k].IsWord() {
+				ends = append(ends, k+1)
+			}
+		}
+		// Try the longest phrases first (a phrase has at least two words).
+		for k := len(ends) - 1; k >= 1; k-- {
+			entry, ok := words.Lookup(PhraseKey(tokens, position, ends[k]))
+			if ok && entry.Level >= 1 && entry.Level <= 3 {
+				spans = append(spans, Span{Start: position, End: ends[k], Level: entry.Level})
+				position = ends[k] - 1
+				break
+			}
+		}
+	}
+	return spans
+}
+
+/*
+PagePhrases method:
+input: the number of a page.
+output: the phrases of the vocabulary on the page, including the ones that start on the previous page and end on this one.
+The phrases of a page are looked for only the first time it is shown: Paginate and ForgetPhrases (when a level
+changes) make us look for them again.
+*/
+
+func (t *Text) PagePhrases(page int) []Span {
+	if page < 0 || page >= len(t.PageList) {
+		return nil
+	}
+	if spans, ok := t.phrases[page]; ok {
+		return spans
+	}
+	// A phrase that ends on the page starts at most MaxPhraseLength-1 words before it.
+	start := t.PageList[page].Start
+	for k := 1; k < MaxPhraseLength; k++ {
+		previous := WordAt(t.TokenList, start-1, -1)
+		if previous == -1 {
+			break
+		}
+		start = previous
+	}
+	spans := FindPhrases(t.TokenList, start, t.PageList[page].End, t.Words)
+	if t.phrases == nil {
+		t.phrases = map[int][]Span{}
+	}
+	t.phrases[page] = spans
+	return spans
+}
+
+// ForgetPhrases forgets the phrases found in the pages, e.g because the level of a word or of a phrase changed.
+func (t *Text) ForgetPhrases() {
+	t.phrases = map[int][]Span{}
+}
@@ -4,7 +4,7 @@
 ** fileReader package **
 This package is responsible for the tokenization of the texts we are going
 to load, as well as the storage of the levels of knowledge of the words in
-a particular language when we are studying.
+a particular language when we are studying (through the vocabulary package).
 This package is also responsible for the creation of "dictionary" files that
 can be exported to anki or memrise.
 
@@ -15,31 +15,34 @@ package fileReader
 
 /*
 Imported packages:
-1) encoding/json --> used to communicate with the locally stored files that store
-our levels of knowledge of words, since they are stored in json.
-2) fmt --> used to print out stuff to the console in case something goes wrong
-3) io/ioutil --> used to work with files
-4) log --> used for error handling
-5) os --> used to work with files
+1) fmt --> used to print out stuff to the console in case something goes wrong
+2) io/ioutil --> used to work with files
+3) log --> used for error handling
+4) os --> used to work with files
 
-We are then importing the terminalSize and the translator packages, to use their features.
-For more info on them, go in the directory ../terminalSize and ../translator
+We are then importing the dictionary, languageHandler, segmenter, translator and vocabulary packages, to use their features.
+For more info on them, go in the directory ../dictionary, ../languageHandler, ../segmenter, ../translator and ../vocabulary
+(the terminalSize package is used in layout.go, to divide the text in pages, and the config package
+in tokenizer.go, to choose the tokenizer of a language).
 
 */
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
-	"net"
 	"os"
 	"strings"
+	"unicode"
 	"unicode/utf8"
 
+	"example.com/packages/dictionary"
 	"example.com/packages/languageHandler"
-	"example.com/packages/terminalSize"
+	"example.com/packages/segmenter"
 	"example.com/packages/translator"
+	"example.com/packages/vocabulary"
+	"github.com/rivo/uniseg"
 )
 
 /*
@@ -48,27 +51,19 @@ This is the struct for the text we will open in our application.
 */
 
 type Text struct {
-	TextContent         string         // This is the actual content of the text.
-	Length              int            // This is the length of the content of the text.
-	TokenList           []string       // This is the list of tokens (or more informally single words) of the text: in european languages, tokens are almost always separated by spaces.
-	TokenCursorPosition int            // This is the current position of our cursor (i.e the current word we're hovering in our application)
-	TokenLength         int            // This is the total number of tokens (words) in the text.
-	Pages               int            // This is the number of pages of the text.
-	PageList            [][]string     // This is the list of tokens in the pages of the text.
-	CurrentPage         int            // This is the number that displays the current page in which we are in.
-	WordLevels          map[string]int // This is the map object that stores the levels of knowledge that we have for a certain word
-	// level of knowledge 0 --> ignore
-	// 1 --> don't know
-	// 2 --> meh
-	// 3 --> know well
-	CurrentTranslate    string // This holds the value of the translation of the word we're currently hovering over (if we requested a translation with the key "5").
+	TextContent         string            // This is the actual content of the text.
+	Length              int               // This is the length of the content of the text.
+	TokenList           []Token           // This is the list of tokens (words and punctuation marks) of the text.
+	TokenCursorPosition int               // This is the current position of our cursor (i.e the current word we're hovering in our application)
+	TokenLength         int               // This is the total number of tokens (words) in the text.
+	Pages               int               // This is the number of pages of the text.
+	PageList            []Page            // This is the list of pages of the text (see layout.go).
+	CurrentPage         int               // This is the number that displays the current page in which we are in.
+	Words               *vocabulary.Store // This is the vocabulary of the language: it stores the levels of knowledge (and the other data) that we have for a certain word
+	CurrentTranslate    string            // This holds the value of the translation of the word we're currently hovering over (if we requested a translation with the key "5").
 	CurrentLatinization string
-}
-
-// Used to communicate with the japanese nlp tokenization python script.
-
-type MyData struct {
-	Tokens []string `json:"tokens"`
+	Filename            string         // This is the path of the text file we opened.
+	phrases             map[int][]Span // The phrases of the vocabulary on every page we showed (see PagePhrases); Paginate empties it.
 }
 
 /*
@@ -96,159 +91,111 @@ func ReturnFileContent(filename string) string {
 /*
 TokenizeText function:
 input: the content of the text (string)
-output: a slice of strings, i.e the list of words (tokens) of the text.
+output: a slice of tokens, i.e the list of words (and punctuation marks) of the text.
 This function is the one that tokenizes the text file.
 */
 
 // (Notice that this tokenization only works for languages that work like
 // european languages [e.g latin, indonesian, tagalog, russian, serbian, italian
 // latin, esperanto exc. ])
+// The text is split following the unicode word boundary rules (UAX #29), so
+// punctuation is separated from the words ("Hello," becomes "Hello" and ","),
+// and every kind of unicode whitespace (non-breaking spaces included) separates words.
 // For chinese there is another tokenization function defined later.
 // For languages like arabic and japanese it's considerably more difficult.
 
-func TokenizeText(text string) []string {
-	// initialize the slice we're going to return
-	var output []string
-	// Loop through the characters of the string
-	i := 0
-	// If the character encountered is an empty space, skip it
-	for string(text[i]) == " " || string(text[i]) == "\n" || string(text[i]) == "\t" {
-		i++
-	}
-	// If there are no more empty spaces, then we can start scanning for an actual word
-	// start scanning word
-	for i < len(text)-1 {
-		// Declare variable j and initialize it to the current value of i.
-		// With this variable j we will store the beginning of the word (token).
-		// With i we will reach the end of the current word (current token).
-		j := i
-		// We keep incrementing i until we hit an empty space (which terminates the current word)
-		for (string(text[i]) != " " && string(text[i]) != "\n" && string(text[i]) != "\t") && i < len(text)-1 {
-			i++
-		}
-		// We then set the token equal to text[j:i], using the string slicing provided by Go.
-		token := text[j:i]
-		// We append the scanned token to the slice we're going to return (which we called "output")
-		output = append(output, token)
-		// Skip other empty spaces
-		if i < len(text)-1 {
-			for (string(text[i]) == " " || string(text[i]) == "\n" || string(text[i]) == "\t") && i < len(text)-1 {
-				i++
-			}
-		}
-	}
-	// Return our slice of tokens.
-	return output
+func TokenizeText(text string) []Token {
+	return TokenizeWithLexicon(text, nil)
 }
 
 /*
-TokenizeChineseText function:
-
-This function provides a tokenization for chinese (both simplified and traditional) texts.
-
+TokenizeWithLexicon function:
+input: the content of the text (string) and the lexicon of the language (it can be nil).
+output: a slice of tokens, i.e the list of words (and punctuation marks) of the text.
+It works like TokenizeText, but the runs of text written in the script of the lexicon
+(e.g a chinese sentence, which has no spaces) are split into words with the lexicon
+(see the segmenter package).
 */
 
-func TokenizeChineseText(text string) []string {
+func TokenizeWithLexicon(text string, lexicon *segmenter.Lexicon) []Token {
 	// initialize the slice we're going to return
-	var output []string
-	// chineseString, _ := utf8.DecodeRuneInString(text)
-	// Loop through the characters of the string
-	// If there are no more empty spaces, then we can start scanning for an actual pictogram
-	// In chinese, we will denote each pictogram as a token.
-	// start scanning
-	for _, char := range text {
-		if string(char) != " " && string(char) != "\n" && string(char) != "\t" {
-			output = append(output, string(char))
+	var output []Token
+	// spaceBefore tells us if we skipped some whitespace before the current segment,
+	// and breaks how many line breaks there were in it.
+	spaceBefore := false
+	breaks := 0
+	// run holds the segments written in the script of the lexicon that we still have to split into words.
+	run := ""
+	appendToken := func(token string) {
+		// If we went on a new line, keep track of it with a layout token.
+		output = appendLayout(output, breaks)
+		// We append the scanned token to the slice we're going to return (which we called "output")
+		output = append(output, NewToken(token, spaceBefore))
+		spaceBefore = false
+		breaks = 0
+	}
+	flushRun := func() {
+		if run != "" {
+			for _, word := range lexicon.Segment(run) {
+				appendToken(word)
+			}
+			run = ""
+		}
+	}
+	state := -1
+	// Loop through the segments of the text: every segment is either a word, a punctuation mark or some whitespace.
+	for len(text) > 0 {
+		var segment string
+		segment, text, state = uniseg.FirstWordInString(text, state)
+		// If the segment is whitespace, skip it (but remember it for the next token).
+		if isBlank(segment) {
+			flushRun()
+			spaceBefore = true
+			breaks += lineBreaks(segment)
+			continue
 		}
+		if lexicon != nil && lexicon.Handles(segment) {
+			run += segment
+			continue
+		}
+		flushRun()
+		appendToken(segment)
 	}
+	flushRun()
 	// Return our slice of tokens.
 	return output
 }
 
 /*
-TokenizeJapaneseText function:
-
-This function provides a tokenization for japanese text (which is not a language that employs spaces to separate words).
-*/
-
-func TokenizeJapaneseText(text string) []string {
-	serverAddr := "127.0.0.1:8080"
-
-	conn, err := net.Dial("tcp", serverAddr)
-	if err != nil {
-		fmt.Println("Error connecting:", err)
-		return []string{}
-	}
-	defer conn.Close()
-
-	stripped := strings.ReplaceAll(text, "\t", "")
-	stripped = strings.ReplaceAll(stripped, " ", "")
-	stripped = strings.ReplaceAll(stripped, "\n", "")
-
-	conn.Write([]byte(stripped))
+TokenizeChineseText function:
 
-	buffer := make([]byte, 262144)
-	bytesRead, err := conn.Read(buffer)
-	if err != nil {
-		fmt.Println("Error reading:", err)
-		return []string{err.Error()}
-	}
+This function provides a tokenization for chinese (both simplified and traditional) texts,
+without the need of any server.
 
-	receivedData := string(buffer[:bytesRead])
-	// Create an instance of your Go struct
-	var myData MyData
+*/
 
-	// Unmarshal the JSON data into the struct
-	err2 := json.Unmarshal([]byte(receivedData), &myData)
-	if err2 != nil {
-		fmt.Println("Error unmarshaling JSON:", err)
-		return []string{err2.Error()}
-	}
-	fmt.Println(myData.Tokens)
-	return myData.Tokens
+func TokenizeChineseText(text string) []Token {
+	return TokenizeSegmentedText(text, "chinese")
 }
 
 /*
-DivideInPages function:
-input: The list of tokens of the text, which strictly speaking is represented as a slice of strings.
-output: a slice of slices of strings (which is basically the list of pages,
-then each page is represented as the list of the tokens inside of it.)
+TokenizeSegmentedText function:
+input: the content of the text and the language it is written in.
+output: the list of tokens of the text.
 
-The following function is responsible for the determination of the number of pages in which the text opened will
-be divided, by taking into account the size of the terminal, making thus the feel of the application responsive.
+This function tokenizes the texts of the languages that are written without spaces between
+the words (chinese, thai, lao, khmer and burmese), by finding the words with the lexicon of
+the language (see the segmenter package), without the need of any server.
 */
 
-func DivideInPages(tokens []string) [][]string {
-	// To see more info on what the 2 methods used below do, check the comments in the terminalSize.go file inside the terminalSize directory.
-	// For now just know that these 2 lines set words and lines equal to the preferred number of words per line and lines per page, by taking into account the size of the terminal.
-	words := terminalSize.GetWordsPerLine()
-	lines := terminalSize.GetLinesPerPage()
-	// The total number of words in a page is thus given by the product of the total number
-	// of words per line with the total number of lines per page.
-	total := words * lines
-
-	// Store the length of the token list of the text inside a variable called length
-	length := len(tokens)
-	// The number of pages is the integer quotient of length by total.
-	pages := (length / total)
-	// Declare the variable we're going to return.
-	var outputSlice [][]string
-	var endIndex int
-	// Form the pages with a loop
-	for i := 0; i < pages; i++ {
-		startIndex := i * total
-		endIndex = (i + 1) * total
-
-		slice := tokens[startIndex:endIndex]
-		outputSlice = append(outputSlice, slice)
+func TokenizeSegmentedText(text string, language string) []Token {
+	lexicon, err := segmenter.LexiconFor(language)
+	if err != nil {
+		// If the lexicon can't be loaded, we fall back to the unicode word boundaries
+		// (e.g every chinese character becomes a token).
+		return TokenizeText(text)
 	}
-	// We deal with the last slice separately.
-	// (we just get the last tokens left)
-	lastSlice := tokens[endIndex:length]
-	// We then append it.
-	outputSlice = append(outputSlice, lastSlice)
-	// Return our slice of slices (which is the list of pages of the text).
-	return outputSlice
+	return TokenizeWithLexicon(text, lexicon)
 }
 
 /*
@@ -271,140 +218,219 @@ func CheckIfContentIsNil(st string) bool {
 }
 
 /*
-MakeJsonFile function:
-inputs: 1) a map (which is how well you know the words in the text)
-2) a language.
-
-This function makes the json file that stores the knowledge data about the
-words in a particular language.
+FileExists function:
+input: the name of the file we're interested in (string)
+output: a boolean value (true/false)
+This function just checks if a file exists.
 */
 
-func MakeJsonFile(data map[string]int, language string) {
-	filename := fmt.Sprintf("languages/%s/words.json", language)
-	jsonData, err1 := json.Marshal(data)
-
-	if err1 != nil {
-		fmt.Println("Error while marshalling the data", err1)
-		return
-	}
+func FileExists(filename string) bool {
+	_, err := os.Stat(filename)
+	return !os.IsNotExist(err)
+}
 
-	file, err2 := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
-	if err2 != nil {
-		fmt.Println("Error opening file:", err2)
-		return
-	}
-	defer file.Close() // Close the file when we're done
+/*
+InitMap function:
+input: language --> which is the current language we're studying
+output: the vocabulary store of the language, which represents how well we know the words we encountered.
 
-	// Write data to the file
-	_, err3 := file.Write(jsonData)
+This function is responsible for the loading of the vocabulary storing the levels of knowledge of the various words we encounter.
+If there is an existing words.json file, it is loaded (and upgraded to the current schema if it was written by an older version of LinGo).
+If not, an empty vocabulary is created.
+*/
 
-	if err3 != nil {
-		fmt.Println("Error writing to file:", err3)
-		return
+func InitMap(language string) *vocabulary.Store {
+	output, err := vocabulary.Open(language)
+	if err != nil {
+		fmt.Println("Error while loading the vocabulary:", err)
 	}
+	return output
 }
 
 /*
-LoadJsonWords function:
+MakeDictFromMenu function
+input: language (string)
+output: it returns the vocabulary store of the language, which contains the words and their levels of knowledge.
+This function allows you to create a dictionary file (i.e a file containing pairs of words in source-target language).
+This file is formatted in such a way that you can import it in both memrise and anki and study the pairs you encountered
+as flash cards.
+The creation of the actual file is done by MakeDictionary and MakeAltDictionary, so this function is just an intermediary.
+*/
 
-input: filepath (string) --> the location in memory of our json file storing the
-word levels.
-output: map --> which is the levels of knowledge of words represented as a Go map.
+func MakeDictFromMenu(language string) *vocabulary.Store {
+	return InitMap(language)
+}
 
-The following function is responsible for loading the levels of knowledge for various words
-starting from a json file storing them into a map object.
-This function is used in the InitMap method, which is very important for the logic of the program.
+/*
+SentenceAt function:
+input: the list of tokens of a text and the position of a token.
+output: the sentence (as a string) that contains the token.
 */
 
-func LoadJsonWords(filepath string) map[string]int {
-	// Read the content of the file (which is Json).
-	// if there is an error, save it in the err variable
-	content, err := ioutil.ReadFile(filepath)
-	// Convert the content we got to string type
-	// in order to work more easily with it throughout the application
-	actualContent := string(content)
-	// Initialize our return value (our map)
-	var data map[string]int
-	// Unmarshal (i.e parse the json) into the Go data map variable data.
-	err2 := json.Unmarshal([]byte(actualContent), &data)
-
-	// If there is an error, tell us.
-	if err2 != nil || err != nil {
-		fmt.Printf("Error while trying to unmarshal json\n")
-	}
-	// return the map.
-	return data
+func SentenceAt(tokens []Token, position int) string {
+	start, end := SentenceBounds(tokens, position)
+	return JoinTokens(tokens[start:end])
 }
 
 /*
-FileExists function:
-input: the name of the file we're interested in (string)
-output: a boolean value (true/false)
-This function just checks if a file exists.
+SentenceBounds function:
+input: the list of tokens of a text and the position of a token.
+output: the range of tokens (from start to end, excluded) of the sentence that contains the token
+(an empty range if the position is out of the text).
+The sentence is delimited by the closest tokens ending with a sentence-ending punctuation mark
+(or by the end of the paragraph).
 */
 
-func FileExists(filename string) bool {
-	_, err := os.Stat(filename)
-	return !os.IsNotExist(err)
+func SentenceBounds(tokens []Token, position int) (int, int) {
+	if position < 0 || position >= len(tokens) {
+		return 0, 0
+	}
+	start := position
+	for start > 0 && !endsSentence(tokens[start-1].Text) && tokens[start-1].Kind != ParagraphToken {
+		start--
+	}
+	end := position
+	for end < len(tokens)-1 && !endsSentence(tokens[end].Text) && tokens[end+1].Kind != ParagraphToken {
+		end++
+	}
+	return start, end + 1
+}
+
+// JoinTokens puts a list of tokens back together, with a space where the text had whitespace
+// (line breaks included, so that the result fits on a single line).
+func JoinTokens(tokens []Token) string {
+	text, _ := JoinTokensWithOffsets(tokens)
+	return text
+}
+
+// JoinTokensWithOffsets works like JoinTokens, but it also returns where every token starts in
+// the result (in bytes; the layout tokens, which don't end up in the result, get -1).
+func JoinTokensWithOffsets(tokens []Token) (string, []int) {
+	var output strings.Builder
+	offsets := make([]int, len(tokens))
+	for k, token := range tokens {
+		offsets[k] = -1
+		if token.IsLayout() {
+			continue
+		}
+		if (token.SpaceBefore || (k > 0 && tokens[k-1].IsLayout())) && output.Len() != 0 {
+			output.WriteString(" ")
+		}
+		offsets[k] = output.Len()
+		output.WriteString(token.Text)
+	}
+	return output.String(), offsets
+}
+
+// endsSentence checks if a token ends with a punctuation mark that terminates a sentence.
+func endsSentence(token string) bool {
+	return strings.HasSuffix(token, ".") || strings.HasSuffix(token, "!") || strings.HasSuffix(token, "?") ||
+		strings.HasSuffix(token, "。") || strings.HasSuffix(token, "！") || strings.HasSuffix(token, "？") || strings.HasSuffix(token, "।")
 }
 
 /*
-InitMap function:
-inputs:
-1) tokens (slice of strings) --> which is the list of tokens of the current text
-2) language --> which is the current language we're studying
-output: a map, which is the map which represents how well we know the words in the text.
-
-This function is responsible for the creation of a map file storing the levels of knowledge of the various words we encounter.
-If there is an existing json files that already has some levels of knowledge for words saved, import that.
-If not, create it.
+LookupTranslation function:
+input: a context (cancelling it stops the requests to the provider), the vocabulary, a word, the language we're
+studying and the language of the interface.
+output: the translation of the word.
+The meaning the user wrote for the word comes first (see vocabulary.Entry), then the translation pinned by the user
+(see translator/cache.go); then, if we already have a translation for the word in our vocabulary (or in the translation
+cache) we use it, otherwise we get it from the main translation provider of the language (see translator/provider.go)
+and store it in the vocabulary.
+The lookup isn't recorded in the vocabulary (see vocabulary.Store.RecordLookup): the reader does it, when the user asks for it.
 */
 
-func InitMap(tokens []string, language string) map[string]int {
-	// This is the location of our words.json file, which stores on our disk (non-volatile memory)
-	// the levels of knowledge of various words for the language we're studying.
-	fileInQuestion := fmt.Sprintf("languages/%s/words.json", language)
-	// If the file exists, load a map object from the file.
-	if FileExists(fileInQuestion) {
-		output := LoadJsonWords(fileInQuestion)
-		return output
-	} else {
-		// If not, create it and initialize all the knowledge levels for the words in the text to 0 (ignore).
-		output := make(map[string]int)
-		for _, token := range tokens {
-			output[token] = 0
-		}
-		MakeJsonFile(output, language)
-		return output
+func LookupTranslation(ctx context.Context, words *vocabulary.Store, word string, language string, bootLanguage string) string {
+	languageId := languageHandler.LanguageMap2[language]
+	if translation, ok := knownTranslation(words, word, language, languageId, bootLanguage); ok {
+		return translation
+	}
+	// The translation can come with an error, if the translation cache couldn't be saved.
+	translation, _ := translator.TranslateFor(ctx, language, word, languageId, bootLanguage, false)
+	if translation != "" {
+		words.Update(word, func(entry *vocabulary.Entry) {
+			entry.Translation = translation
+		})
 	}
+	return translation
 }
 
 /*
-MakeDictFromMenu function
-input: language (string)
-output: it returns a map, which contains as keys the words and as values the levels of knowledge.
-This function allows you to create a dictionary file (i.e a file containing pairs of words in source-target language).
-This file is formatted in such a way that you can import it in both memrise and anki and study the pairs you encountered
-as flash cards.
-The creation of the actual file is done in the main file, so this function is just an intermediary. In fact, it returns a
-map as you can see. From this map we then quickly create the file we cited above.
+LookupLatinization function:
+it works like LookupTranslation, but for the latinization of the word, and it returns the error of the
+latinization (the latinization is only stored when there's none). If the offline dictionaries of the
+language give the reading of the word in the latin alphabet (like the pinyin of CC-CEDICT), that reading
+is used.
 */
 
-func MakeDictFromMenu(language string) map[string]int {
-	fileInQuestion := fmt.Sprintf("languages/%s/words.json", language)
-	if FileExists(fileInQuestion) {
-		output := LoadJsonWords(fileInQuestion)
-		return output
-	} else {
-		return map[string]int{}
+func LookupLatinization(ctx context.Context, words *vocabulary.Store, word string, language string, hanziData map[string][]string) (string, error) {
+	if latinization, ok := knownLatinization(words, word, language); ok {
+		return latinization, nil
+	}
+	latinization, err := translator.LatinizeTextContext(ctx, word, hanziData, language)
+	if err != nil {
+		return "", err
+	}
+	saveLatinization(words, word, latinization)
+	return latinization, nil
+}
+
+// knownLatinization returns the latinization of a word we already have (in the vocabulary, or in the offline dictionaries), and whether there's one.
+func knownLatinization(words *vocabulary.Store, word string, language string) (string, bool) {
+	if entry, ok := words.Lookup(word); ok && entry.Latinization != "" {
+		return entry.Latinization, true
+	}
+	if reference, ok := referenceEntry(language, word); ok && isLatin(reference.Reading) {
+		saveLatinization(words, word, reference.Reading)
+		return reference.Reading, true
+	}
+	return "", false
+}
+
+// saveLatinization stores the latinization of a word in the vocabulary.
+func saveLatinization(words *vocabulary.Store, word string, latinization string) {
+	if latinization != "" {
+		words.Update(word, func(entry *vocabulary.Entry) {
+			entry.Latinization = latinization
+		})
 	}
 }
 
+// referenceEntry returns the first entry of a word in the offline dictionaries of a language that splits the senses
+// of the word (like the CC-CEDICT and JMdict ones do), and whether there's one.
+func referenceEntry(language string, word string) (dictionary.Entry, bool) {
+	library, _ := dictionary.ForLanguage(language)
+	if library == nil {
+		return dictionary.Entry{}, false
+	}
+	entries, _ := library.Lookup(word)
+	for _, entry := range entries {
+		if len(entry.Senses) > 0 {
+			return entry, true
+		}
+	}
+	return dictionary.Entry{}, false
+}
+
+// isLatin tells if a text is written in the latin alphabet (and has at least a letter).
+func isLatin(text string) bool {
+	letters := 0
+	for _, char := range text {
+		if unicode.IsLetter(char) {
+			if !unicode.Is(unicode.Latin, char) {
+				return false
+			}
+			letters++
+		}
+	}
+	return letters > 0
+}
+
 /*
 MakeDictionary function
 
 This function is responsible for the actual creation of the dictionary file that can then
-be exported to Anki,memrise and other flashcard systems. It takes in a map[string]int which represents
+be exported to Anki,memrise and other flashcard systems. It takes in the vocabulary store which contains
 the levels of knowledge of determinate words, and the target language we're studying.
 With these informations it then creates a file that contains couplets of the form:
 
@@ -417,42 +443,20 @@ gracias, thanks
 como estas, how are you
 
 These files can then be exported and made into flashcards using Anki or memrise.
+The words are translated by a pool of workers (see export.go): the function gets a context (cancelling it stops the export)
+and a function that is told how many words are done; it returns what happened (e.g the words that couldn't be translated)
+and a possible error.
 */
 
-func MakeDictionary(data map[string]int, language string, bootLanguage string) {
-	// Path where we will save our dictionary file.
-	filename := fmt.Sprintf("languages/%s/dictionary.txt", language)
-	// Declare the finalString variable and initialize it to empty string "".
-	// this is the content (as a string) of our dictionary.txt file.
-	finalString := ""
-	finalString += "\n"
-	// Loop through the key value pairs of the data map we passed in.
-	for k, v := range data {
-		// If we don't know a word (i.e if it has code 1 or 2)
-		// save it into the dictionary
-		if v == 1 || v == 2 {
-			// get the translation via the API
-			languageId := languageHandler.LanguageMap2[language]
-			translation, _ := translator.Translate2(k, languageId, bootLanguage)
-			// append to the finalString
-			finalString += fmt.Sprintf("%s, %s\n", k, translation)
+func MakeDictionary(ctx context.Context, words *vocabulary.Store, language string, bootLanguage string, progress func(done int, total int)) (ExportResult, error) {
+	return exportDictionary(ctx, words, language, bootLanguage, progress, func(e *exporter, word string) (string, error) {
+		// get the translation from the vocabulary (or via the API if we never translated the word)
+		translation, err := e.translate(word)
+		if err != nil {
+			return "", err
 		}
-	}
-	// Use the os.Openfile to open file; if it doesnt exist, it automatically creates it.
-	file, err2 := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
-	if err2 != nil {
-		fmt.Println("Error opening file:", err2)
-		return
-	}
-	defer file.Close() // Close the file when we're done
-
-	// Write data to the file
-	_, err3 := file.Write([]byte(finalString))
-
-	if err3 != nil {
-		fmt.Println("Error writing to file:", err3)
-		return
-	}
+		return fmt.Sprintf("%s, %s", word, translation), nil
+	})
 }
 
 /*
@@ -460,7 +464,7 @@ MakeAltDictionary function
 
 It differs from the MakeDictionary function since this makes flashcards with latinization included too
 This function is responsible for the actual creation of the dictionary file that can then
-be exported to Anki,memrise and other flashcard systems. It takes in a map[string]int which represents
+be exported to Anki,memrise and other flashcard systems. It takes in the vocabulary store which contains
 the levels of knowledge of determinate words, and the target language we're studying.
 With these informations it then creates a file that contains couplets of the form:
 
@@ -471,103 +475,89 @@ Example:
 привет, hello (privet)
 ...
 
+When the offline dictionaries of the language split the senses of the words (CC-CEDICT, JMdict), they are
+used too: the senses are the translation (unless we already have one, or we wrote our own meaning), the reading is
+added to the latinization when it isn't in the latin alphabet (e.g the kana of a japanese word), and the tags of the
+word follow in brackets:
+
+猫, (n) cat; (n, uk) shamisen (ねこ, neko) [common, JLPT N5]
+
 These files can then be exported and made into flashcards using Anki or memrise.
+It works in the background like MakeDictionary.
 */
 
-func MakeAltDictionary(data map[string]int, language string, bootLanguage string, hanziData map[string][]string) {
-	// Path where we will save our dictionary file.
-	filename := fmt.Sprintf("languages/%s/dictionary.txt", language)
-	// Declare the finalString variable and initialize it to empty string "".
-	// this is the content (as a string) of our dictionary.txt file.
-	finalString := ""
-	finalString += "\n"
-	// Loop through the key value pairs of the data map we passed in.
-	for k, v := range data {
-		// If we don't know a word (i.e if it has code 1 or 2)
-		// save it into the dictionary
-		if v == 1 || v == 2 {
-			// get the translation via the API
-			languageId := languageHandler.LanguageMap2[language]
-			translation, _ := translator.Translate2(k, languageId, bootLanguage)
-			latinization := translator.LatinizeText(k, hanziData, language)
-			// append to the finalString
-			finalString += fmt.Sprintf("%s, %s (%s)\n", k, translation, latinization)
+func MakeAltDictionary(ctx context.Context, words *vocabulary.Store, language string, bootLanguage string, hanziData map[string][]string, progress func(done int, total int)) (ExportResult, error) {
+	return exportDictionary(ctx, words, language, bootLanguage, progress, func(e *exporter, word string) (string, error) {
+		// get the translation and the latinization from the vocabulary (or compute them if we don't have them yet)
+		reference, found := referenceEntry(language, word)
+		var translation string
+		if entry, ok := words.Lookup(word); found && (!ok || (entry.Translation == "" && entry.Meaning == "")) {
+			translation = strings.Join(reference.Senses, "; ")
+		} else {
+			var err error
+			if translation, err = e.translate(word); err != nil {
+				return "", err
+			}
 		}
-	}
-	// Use the os.Openfile to open file; if it doesnt exist, it automatically creates it.
-	file, err2 := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
-	if err2 != nil {
-		fmt.Println("Error opening file:", err2)
-		return
-	}
-	defer file.Close() // Close the file when we're done
-
-	// Write data to the file
-	_, err3 := file.Write([]byte(finalString))
-
-	if err3 != nil {
-		fmt.Println("Error writing to file:", err3)
-		return
-	}
+		latinization, err := e.latinize(word, hanziData)
+		if err != nil {
+			return "", err
+		}
+		if found && reference.Reading != "" && !isLatin(reference.Reading) && reference.Reading != word {
+			if latinization == "" {
+				latinization = reference.Reading
+			} else {
+				latinization = reference.Reading + ", " + latinization
+			}
+		}
+		line := fmt.Sprintf("%s, %s (%s)", word, translation, latinization)
+		if found && len(reference.Tags) > 0 {
+			line += " [" + strings.Join(reference.Tags, ", ") + "]"
+		}
+		return line, nil
+	})
 }
 
 /*
 InitText function:
 input: the name of the file we opened(string) and the current language we're studying (a string).
-output: a Text object
+output: a Text object, and a possible error (e.g if the tokenization server of the language isn't running)
 
 The following function is responsible for the creation (from this the name InitText)
 of a Text struct, which represents the current text opened in the application.
+The text isn't divided in pages yet, since that depends on the size of the terminal: call Paginate for that.
 */
 
-func InitText(filename string, language string) Text {
+func InitText(filename string, language string) (Text, error) {
+	text, err := initText(filename, language)
+	// Place the cursor on the first word of the text (the text might start with punctuation).
+	if first := WordAt(text.TokenList, 0, 1); first != -1 {
+		text.TokenCursorPosition = first
+	}
+	return text, err
+}
+
+func initText(filename string, language string) (Text, error) {
 	// Get the content inside the file as a string.
 	content := ReturnFileContent(filename)
-	// Initialize the cursor position to 0 (i.e to the start).
-	var currentCursor int = 0
-	// if the file has some characters which are not empty spaces, tabs or new lines
-	// and is not chinese, then do the following
-	if !CheckIfContentIsNil(content) && language != "chinese" && language != "japanese" && language != "thai" && language != "khmer" && language != "burmese" && language != "lao" {
-		// Calculate the length of the content inside the file.
-		var contentLength = len(content)
-		// Tokenize the text (i.e split it in tokens) using the TokenizeText function
-		TokenList := TokenizeText(content)
-		// Get the list of pages; each page will be a list of tokens.
-		pageList := DivideInPages(TokenList)
-		// initialize a word map using the InitMap function: this denotes the level of knowledge of the words inside the text
-		// in a particular language.
-		var wordsMap = InitMap(TokenList, language)
-		// Create outputText object
-		outputText := Text{TextContent: content, Length: contentLength, TokenList: TokenList, TokenCursorPosition: currentCursor, TokenLength: len(TokenList), CurrentPage: 0, PageList: pageList, Pages: len(pageList), WordLevels: wordsMap}
-		// Return it.
-		return outputText
-	} else if !CheckIfContentIsNil(content) && language == "chinese" {
-		// Calculate the length of the content inside the file.
-		// In this case, since we're dealing with chinese, we will have to use the utf8.RuneCountInString method instead.
-		// This will actually count the number of characters, in contrast to the "len" function which will just return the byte length.
-		var contentLength = utf8.RuneCountInString(content)
-		TokenList := TokenizeJapaneseText(content)
-		pageList := DivideInPages(TokenList)
-		var wordsMap = InitMap(TokenList, language)
-		outputText := Text{TextContent: content, Length: contentLength, TokenList: TokenList, TokenCursorPosition: currentCursor, TokenLength: len(TokenList), CurrentPage: 0, PageList: pageList, Pages: len(pageList), WordLevels: wordsMap}
-		return outputText
-	} else if !CheckIfContentIsNil(content) && (language == "japanese" || language == "khmer" || language == "thai" || language == "burmese" || language == "lao") {
-		// Calculate the length of the content inside the file.
-		// In this case, since we're dealing with chinese, we will have to use the utf8.RuneCountInString method instead.
-		// This will actually count the number of characters, in contrast to the "len" function which will just return the byte length.
-		var contentLength = utf8.RuneCountInString(content)
-		TokenList := TokenizeJapaneseText(content)
-		pageList := DivideInPages(TokenList)
-		var wordsMap = InitMap(TokenList, language)
-		outputText := Text{TextContent: content, Length: contentLength, TokenList: TokenList, TokenCursorPosition: currentCursor, TokenLength: len(TokenList), CurrentPage: 0, PageList: pageList, Pages: len(pageList), WordLevels: wordsMap}
-		return outputText
-	} else {
+	// load the vocabulary using the InitMap function: this denotes the level of knowledge of the words inside the text
+	// in a particular language.
+	wordsMap := InitMap(language)
+	// if the file only has empty spaces, tabs or new lines, let the user know.
+	if CheckIfContentIsNil(content) {
 		content = "Text file is empty. Are you sure you opened the right one?"
-		// Calculate the length of the content inside the file.
-		var contentLength = len(content)
 		TokenList := TokenizeText(content)
-		pageList := DivideInPages(TokenList)
-		outputText := Text{TextContent: content, Length: contentLength, TokenList: TokenList, TokenCursorPosition: currentCursor, TokenLength: len(TokenList), CurrentPage: 0, PageList: pageList, Pages: len(pageList)}
-		return outputText
+		return Text{TextContent: content, Length: utf8.RuneCountInString(content), TokenList: TokenList, TokenLength: len(TokenList), Words: wordsMap, Filename: filename}, nil
+	}
+	// Tokenize the text (i.e split it in tokens) with the tokenizer of the language (see tokenizer.go);
+	// if the tokenizer is slow, the tokens are cached on disk (see cache.go).
+	var TokenList []Token
+	tokenizer, err := TokenizerFor(language)
+	if err == nil {
+		TokenList, err = TokenizeCached(tokenizer, language, filename, content)
 	}
+	// Calculate the length of the content inside the file: we use the utf8.RuneCountInString function, which
+	// counts the number of characters, in contrast to the "len" function which would just return the byte length.
+	outputText := Text{TextContent: content, Length: utf8.RuneCountInString(content), TokenList: TokenList, TokenLength: len(TokenList), Words: wordsMap, Filename: filename}
+	return outputText, err
 }
@@ -0,0 +1,278 @@
+package fileReader
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+The protocol used to talk with the tokenization servers (see scriptioContinuaTokenization/lingo_protocol.py).
+
+Every message is a json object on a single line. We send the lines of (a chunk of) the text:
+	{"id": 1, "lines": ["first line", "", "second paragraph"]}
+and the server answers with the tokens of every line:
+	{"id": 1, "tokens": [["first", "line"], [], ["second", "paragraph"]]}
+or with {"id": 1, "error": "..."} if something went wrong.
+A request without lines is a health check; the server answers it with its version, e.g
+	{"id": 1, "tokens": [], "version": "spacy 3.7.2 ja_core_news_sm 3.7.0"}
+so that we know when the tokens we cached (see cache.go) are out of date.
+Since every line is tokenized on its own, the line breaks and the paragraphs of the text survive
+the round trip; long texts are sent in chunks of at most ChunkSize bytes over the same connection.
+*/
+
+const (
+	ChunkSize      = 16 * 1024        // The maximum size (in bytes) of the text sent in a single request
+	DialTimeout    = 5 * time.Second  // How long we wait to connect to the server
+	RequestTimeout = 60 * time.Second // How long we wait for the server to answer a request
+	HealthTimeout  = 2 * time.Second  // How long we wait for the server to answer a health check
+)
+
+/*
+ServerDownError struct:
+the error we get when the tokenization server of a language can't be reached.
+*/
+
+type ServerDownError struct {
+	Address string // The address of the server
+	Err     error  // What went wrong
+}
+
+func (e *ServerDownError) Error() string {
+	return fmt.Sprintf("the tokenization server at %s is not reachable: %v", e.Address, e.Err)
+}
+
+func (e *ServerDownError) Unwrap() error {
+	return e.Err
+}
+
+/*
+dial function:
+input: the address of a server, either host:port or unix:/path/of/the/socket, and a timeout.
+output: the connection to the server, and a possible error (a *ServerDownError).
+*/
+
+func dial(address string, timeout time.Duration) (net.Conn, error) {
+	network, target := "tcp", address
+	if strings.HasPrefix(address, "unix:") {
+		network, target = "unix", strings.TrimPrefix(address, "unix:")
+	}
+	conn, err := net.DialTimeout(network, target, timeout)
+	if err != nil {
+		return nil, &ServerDownError{Address: address, Err: err}
+	}
+	return conn, nil
+}
+
+/*
+CheckServer function:
+input: the address of a tokenization server.
+output: nil if the server is up and speaks our protocol, an error otherwise.
+*/
+
+func CheckServer(address string) error {
+	_, err := ServerVersion(address)
+	return err
+}
+
+/*
+ServerVersion function:
+input: the address of a tokenization server.
+output: the version of the server (it can be empty, if the server doesn't say it), and an error
+if the server isn't up or doesn't speak our protocol.
+We send a request without any line, and wait for the (empty) answer.
+*/
+
+func ServerVersion(address string) (string, error) {
+	conn, err := dial(address, HealthTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(HealthTimeout))
+	request := serverRequest{ID: requestIDs.Add(1), Lines: []string{}}
+	if err := json.NewEncoder(conn).Encode(request); err != nil {
+		return "", &ServerDownError{Address: address, Err: err}
+	}
+	message, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return "", &ServerDownError{Address: address, Err: err}
+	}
+	var response serverResponse
+	if err := json.Unmarshal(message, &response); err != nil || response.ID != request.ID {
+		return "", &ServerDownError{Address: address, Err: fmt.Errorf("the server doesn't speak the LinGo protocol")}
+	}
+	return response.Version, nil
+}
+
+// serverRequest is a request sent to a tokenization server.
+type serverRequest struct {
+	ID    int64    `json:"id"`
+	Lines []string `json:"lines"`
+}
+
+// serverResponse is the answer of a tokenization server.
+type serverResponse struct {
+	ID      int64      `json:"id"`
+	Tokens  [][]string `json:"tokens"`
+	Error   string     `json:"error"`
+	Version string     `json:"version,omitempty"` // Only in the answers to the health checks
+}
+
+// requestIDs gives a different id to every request.
+var requestIDs atomic.Int64
+
+// piece is a part of a line of the text; long lines are divided in many pieces.
+type piece struct {
+	text      string
+	continued bool // Whether the piece continues the line of the previous piece
+}
+
+/*
+TokenizeWithServer function:
+input: the address of a tokenization server (host:port or unix:/path/of/the/socket) and the content of the text.
+output: the list of tokens of the text, and a possible error.
+*/
+
+func TokenizeWithServer(address string, text string) ([]Token, error) {
+	conn, err := dial(address, DialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	encoder := json.NewEncoder(conn)
+
+	var output []Token
+	// breaks is the number of line breaks since the last token.
+	breaks := 0
+	for _, chunk := range chunkPieces(splitInPieces(text), ChunkSize) {
+		lines := make([]string, len(chunk))
+		for k, piece := range chunk {
+			lines[k] = piece.text
+		}
+		request := serverRequest{ID: requestIDs.Add(1), Lines: lines}
+		conn.SetDeadline(time.Now().Add(RequestTimeout))
+		// Encode ends the message with a new line.
+		if err := encoder.Encode(request); err != nil {
+			return nil, fmt.Errorf("can't send the text to the tokenization server: %w", err)
+		}
+		message, err := reader.ReadBytes('\n')
+		if err != nil {
+			return nil, fmt.Errorf("can't read the answer of the tokenization server: %w", err)
+		}
+		var response serverResponse
+		if err := json.Unmarshal(message, &response); err != nil {
+			return nil, fmt.Errorf("can't parse the answer of the tokenization server: %w", err)
+		}
+		if response.Error != "" {
+			return nil, fmt.Errorf("the tokenization server failed: %s", response.Error)
+		}
+		if response.ID != request.ID || len(response.Tokens) != len(chunk) {
+			return nil, fmt.Errorf("the tokenization server sent an answer that doesn't match the request")
+		}
+		for k, piece := range chunk {
+			if !piece.continued && (k > 0 || len(output) > 0) {
+				breaks++
+			}
+			for _, token := range alignTokens(piece.text, response.Tokens[k]) {
+				output = appendLayout(output, breaks)
+				output = append(output, token)
+				breaks = 0
+			}
+		}
+	}
+	return output, nil
+}
+
+// splitInPieces divides the text in lines, and the lines longer than ChunkSize in many pieces.
+func splitInPieces(text string) []piece {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var pieces []piece
+	for _, line := range strings.Split(text, "\n") {
+		continued := false
+		for len(line) > ChunkSize {
+			cut := cutPoint(line, ChunkSize)
+			pieces = append(pieces, piece{text: line[:cut], continued: continued})
+			line = line[cut:]
+			continued = true
+		}
+		pieces = append(pieces, piece{text: line, continued: continued})
+	}
+	return pieces
+}
+
+/*
+cutPoint function:
+input: a line and a maximum size.
+output: where to cut the line so that the first part isn't bigger than the maximum size: before the
+last space or after the last sentence-ending punctuation mark, or (if there's none) between two characters.
+*/
+
+func cutPoint(line string, max int) int {
+	for k := max; k > 0; {
+		char, size := utf8.DecodeLastRuneInString(line[:k])
+		if unicode.IsSpace(char) && k-size > 0 {
+			return k - size
+		}
+		if endsSentence(string(char)) {
+			return k
+		}
+		k -= size
+	}
+	// There's no good place to cut the line: just don't cut a character in half.
+	k := max
+	for k > 0 && !utf8.RuneStart(line[k]) {
+		k--
+	}
+	return k
+}
+
+// chunkPieces groups the pieces in chunks of at most size bytes (a chunk always has at least one piece).
+func chunkPieces(pieces []piece, size int) [][]piece {
+	var chunks [][]piece
+	var chunk []piece
+	used := 0
+	for _, piece := range pieces {
+		if len(chunk) > 0 && used+len(piece.text) > size {
+			chunks = append(chunks, chunk)
+			chunk, used = nil, 0
+		}
+		chunk = append(chunk, piece)
+		used += len(piece.text)
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+/*
+alignTokens function:
+input: a piece of the text and the tokens the server found in it.
+output: the tokens; we look for every token in the text, so that we know if it was preceded by
+whitespace (if the server changed a token and we can't find it, we assume it was).
+*/
+
+func alignTokens(text string, tokens []string) []Token {
+	var output []Token
+	position := 0
+	for _, token := range tokens {
+		if isBlank(token) {
+			continue
+		}
+		spaceBefore := true
+		if index := strings.Index(text[position:], token); index != -1 {
+			spaceBefore = strings.ContainsFunc(text[position:position+index], unicode.IsSpace)
+			position += index + len(token)
+		}
+		output = append(output, NewToken(token, spaceBefore))
+	}
+	return output
+}
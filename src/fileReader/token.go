@@ -0,0 +1,132 @@
+package fileReader
+
+import (
+	"strings"
+	"unicode"
+
+	"example.com/packages/vocabulary"
+	"github.com/rivo/uniseg"
+)
+
+/*
+TokenKind type:
+it tells us what a token is. The cursor only moves over word tokens, and only
+word tokens have a level of knowledge.
+Newline and paragraph tokens don't contain any text: they only tell us where the
+text went on a new line, so that we can keep its layout when we display it.
+*/
+
+type TokenKind int
+
+const (
+	WordToken        TokenKind = iota // A word (or a number)
+	PunctuationToken                  // Punctuation marks, symbols exc.
+	NewlineToken                      // A single line break
+	ParagraphToken                    // Two or more line breaks in a row (i.e an empty line between two paragraphs)
+)
+
+/*
+Token struct:
+a single token of a text.
+We keep the token as it appears in the text (which is what we display) separate
+from the key under which it is stored in the vocabulary, so that "Hello," and
+"hello" share the same level of knowledge.
+*/
+
+type Token struct {
+	Text        string    // The token as it appears in the text
+	Key         string    // The normalized key of the token (see vocabulary.Key); empty for punctuation
+	Kind        TokenKind // Whether the token is a word or punctuation
+	SpaceBefore bool      // Whether the token was preceded by whitespace in the text
+}
+
+// NewToken classifies a piece of text and computes its key.
+func NewToken(text string, spaceBefore bool) Token {
+	for _, char := range text {
+		if unicode.IsLetter(char) || unicode.IsNumber(char) || unicode.IsMark(char) {
+			return Token{Text: text, Key: vocabulary.Key(text), Kind: WordToken, SpaceBefore: spaceBefore}
+		}
+	}
+	return Token{Text: text, Kind: PunctuationToken, SpaceBefore: spaceBefore}
+}
+
+// IsLayout checks if the token is a line or paragraph break.
+func (t Token) IsLayout() bool {
+	return t.Kind == NewlineToken || t.Kind == ParagraphToken
+}
+
+/*
+Width method:
+it returns how many cells of the terminal the token takes when we display it:
+East Asian wide characters take 2 cells, combining marks don't take any.
+*/
+
+func (t Token) Width() int {
+	if t.IsLayout() {
+		return 0
+	}
+	return uniseg.StringWidth(t.Text)
+}
+
+// IsWord checks if the token is a word (i.e the cursor can stop on it).
+func (t Token) IsWord() bool {
+	return t.Kind == WordToken
+}
+
+// lineBreaks counts the line breaks in a piece of whitespace ("\r\n" counts as one).
+func lineBreaks(text string) int {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Count(text, "\n") + strings.Count(text, "\r")
+}
+
+/*
+appendLayout function:
+input: the tokens we scanned so far and the number of line breaks we found since the last token.
+output: the tokens, followed by a newline token (one line break) or a paragraph token (more than one).
+We never start a text with a layout token, since there's nothing to separate.
+*/
+
+func appendLayout(tokens []Token, breaks int) []Token {
+	if breaks == 0 || len(tokens) == 0 {
+		return tokens
+	}
+	if breaks == 1 {
+		return append(tokens, Token{Kind: NewlineToken})
+	}
+	return append(tokens, Token{Kind: ParagraphToken})
+}
+
+// isBlank checks if a string is only made of whitespace.
+func isBlank(text string) bool {
+	for _, char := range text {
+		if !unicode.IsSpace(char) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+WordAt function:
+input: the list of tokens, a position and a direction (1 to go forward, -1 to go backwards).
+output: the position of the closest word token starting from position (included) in the given
+direction, or -1 if there isn't one.
+*/
+
+func WordAt(tokens []Token, position int, direction int) int {
+	for position >= 0 && position < len(tokens) {
+		if tokens[position].IsWord() {
+			return position
+		}
+		position += direction
+	}
+	return -1
+}
+
+// CurrentToken returns the token under the cursor (an empty token if the text has no tokens).
+func (t Text) CurrentToken() Token {
+	if t.TokenCursorPosition < 0 || t.TokenCursorPosition >= len(t.TokenList) {
+		return Token{}
+	}
+	return t.TokenList[t.TokenCursorPosition]
+}
@@ -0,0 +1,292 @@
+package fileReader
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"unicode"
+
+	"example.com/packages/config"
+	"example.com/packages/segmenter"
+	"github.com/rivo/uniseg"
+)
+
+/*
+Tokenizer interface:
+a way of splitting a text into tokens. Every language has its own tokenizer (see TokenizerFor):
+most languages separate their words with spaces, while others need a lexicon or an external server.
+*/
+
+type Tokenizer interface {
+	// Tokenize splits a text into tokens.
+	Tokenize(text string) ([]Token, error)
+	// Name describes the tokenizer, e.g "dictionary" or "server 127.0.0.1:8080".
+	Name() string
+}
+
+/*
+HealthChecker interface:
+it is implemented by the tokenizers that depend on something that might not be running (i.e a server),
+so that we can warn the user before they open a text.
+*/
+
+type HealthChecker interface {
+	Check() error
+}
+
+/*
+Versioned interface:
+it is implemented by the tokenizers that are slow enough for their tokens to be cached on disk (see cache.go).
+The version changes whenever the same text could be split in a different way (e.g when the lexicon or the
+model of the server changes); an empty version means that we don't know it (e.g the server is down).
+*/
+
+type Versioned interface {
+	Version() string
+}
+
+/*
+ManagedServer interface:
+it is implemented by the tokenizers that talk with a server LinGo can start by itself
+(see the serverManager package).
+*/
+
+type ManagedServer interface {
+	// Address is where the server listens.
+	Address() string
+	// Command is the command that starts the server; it's empty if LinGo shouldn't start it.
+	Command() []string
+}
+
+/*
+DefaultServerAddresses map:
+the address every server in the scriptioContinuaTokenization folder listens on if we don't tell it otherwise;
+every language has its own port, so that the servers of different languages can run at the same time.
+*/
+
+var DefaultServerAddresses = map[string]string{
+	"japanese": "127.0.0.1:8080",
+	"chinese":  "127.0.0.1:8081",
+	"thai":     "127.0.0.1:8082",
+	"lao":      "127.0.0.1:8083",
+	"khmer":    "127.0.0.1:8084",
+	"burmese":  "127.0.0.1:8085",
+}
+
+// FallbackServerAddress is the address of the server of the languages that don't have a default one.
+const FallbackServerAddress = "127.0.0.1:8080"
+
+/*
+defaultTokenizers map:
+the tokenizers LinGo uses when setup/config.json doesn't say anything about a language;
+the languages that aren't in the map use the whitespace tokenizer.
+*/
+
+var defaultTokenizers = map[string]config.Tokenizer{
+	"chinese":  {Type: "server"},
+	"thai":     {Type: "server"},
+	"lao":      {Type: "server"},
+	"khmer":    {Type: "server"},
+	"burmese":  {Type: "server"},
+	"japanese": {Type: "server"},
+}
+
+var (
+	tokenizers   = map[string]Tokenizer{}
+	tokenizersMu sync.Mutex
+)
+
+/*
+TokenizerFor function:
+input: a language.
+output: the tokenizer of the language, and a possible error (e.g if the settings are wrong).
+The tokenizer is chosen by the "tokenizers" setting in setup/config.json if the language is
+there, otherwise by the defaultTokenizers map; it is created once and then reused.
+*/
+
+func TokenizerFor(language string) (Tokenizer, error) {
+	tokenizersMu.Lock()
+	defer tokenizersMu.Unlock()
+	if tokenizer, ok := tokenizers[language]; ok {
+		return tokenizer, nil
+	}
+	settings, ok := config.Get().Tokenizers[language]
+	if !ok {
+		settings, ok = defaultTokenizers[language]
+	}
+	if !ok {
+		settings = config.Tokenizer{Type: "whitespace"}
+	}
+	tokenizer, err := NewTokenizer(language, settings)
+	if err != nil {
+		return nil, fmt.Errorf("tokenizer of %s: %w", language, err)
+	}
+	tokenizers[language] = tokenizer
+	return tokenizer, nil
+}
+
+/*
+NewTokenizer function:
+input: a language and the settings of its tokenizer.
+output: the tokenizer, and a possible error.
+*/
+
+func NewTokenizer(language string, settings config.Tokenizer) (Tokenizer, error) {
+	switch settings.Type {
+	case "whitespace", "":
+		return whitespaceTokenizer{}, nil
+	case "rune":
+		return runeTokenizer{}, nil
+	case "dictionary":
+		if len(settings.Lexicon) == 0 {
+			lexicon, err := segmenter.LexiconFor(language)
+			if err != nil {
+				return nil, err
+			}
+			return dictionaryTokenizer{lexicon: lexicon}, nil
+		}
+		script, ok := unicode.Scripts[settings.Script]
+		if !ok {
+			return nil, fmt.Errorf("unknown script %q (it should be the name of a unicode script, like \"Han\" or \"Thai\")", settings.Script)
+		}
+		lexicon, err := segmenter.LoadLexicon(script, segmenter.MaximalMatching, settings.Lexicon)
+		if err != nil {
+			return nil, err
+		}
+		return dictionaryTokenizer{lexicon: lexicon}, nil
+	case "server":
+		address := settings.Address
+		if address == "" {
+			address = DefaultServerAddresses[language]
+		}
+		if address == "" {
+			address = FallbackServerAddress
+		}
+		command := settings.Command
+		if len(command) == 0 {
+			command = defaultServerCommand(language, address)
+		}
+		if settings.External {
+			command = nil
+		}
+		return serverTokenizer{address: address, command: command}, nil
+	default:
+		return nil, fmt.Errorf("unknown tokenizer type %q", settings.Type)
+	}
+}
+
+// whitespaceTokenizer splits the text on the unicode word boundaries (see TokenizeText).
+type whitespaceTokenizer struct{}
+
+func (whitespaceTokenizer) Tokenize(text string) ([]Token, error) {
+	return TokenizeText(text), nil
+}
+
+func (whitespaceTokenizer) Name() string {
+	return "whitespace"
+}
+
+// runeTokenizer makes every character a token (see TokenizeCharacters).
+type runeTokenizer struct{}
+
+func (runeTokenizer) Tokenize(text string) ([]Token, error) {
+	return TokenizeCharacters(text), nil
+}
+
+func (runeTokenizer) Name() string {
+	return "rune"
+}
+
+// dictionaryTokenizer finds the words with a lexicon (see TokenizeWithLexicon).
+type dictionaryTokenizer struct {
+	lexicon *segmenter.Lexicon
+}
+
+func (t dictionaryTokenizer) Tokenize(text string) ([]Token, error) {
+	return TokenizeWithLexicon(text, t.lexicon), nil
+}
+
+func (dictionaryTokenizer) Name() string {
+	return "dictionary"
+}
+
+func (t dictionaryTokenizer) Version() string {
+	// The checksum changes whenever the words change, even if there are as many as before.
+	return fmt.Sprintf("strategy %d, %d words, %016x", t.lexicon.Strategy, t.lexicon.Size(), t.lexicon.Checksum())
+}
+
+/*
+defaultServerCommand function:
+input: a language and the address of its server.
+output: the command that starts the server of the language in the scriptioContinuaTokenization
+folder on that address, or nil if there's no server for the language.
+*/
+
+func defaultServerCommand(language string, address string) []string {
+	script := fmt.Sprintf("scriptioContinuaTokenization/server_%s.py", language)
+	if _, err := os.Stat(script); err != nil {
+		return nil
+	}
+	return []string{"python3", script, address}
+}
+
+// serverTokenizer asks an external tokenization server (e.g scriptioContinuaTokenization/server_japanese.py).
+type serverTokenizer struct {
+	address string
+	command []string // The command that starts the server (empty if LinGo shouldn't start it)
+}
+
+func (t serverTokenizer) Name() string {
+	return "server " + t.address
+}
+
+func (t serverTokenizer) Tokenize(text string) ([]Token, error) {
+	return TokenizeWithServer(t.address, text)
+}
+
+func (t serverTokenizer) Version() string {
+	version, err := ServerVersion(t.address)
+	if err != nil {
+		return ""
+	}
+	return version
+}
+
+func (t serverTokenizer) Check() error {
+	return CheckServer(t.address)
+}
+
+func (t serverTokenizer) Address() string {
+	return t.address
+}
+
+func (t serverTokenizer) Command() []string {
+	return t.command
+}
+
+/*
+TokenizeCharacters function:
+input: the content of the text.
+output: the list of tokens of the text, where every character (together with its combining marks) is a token.
+*/
+
+func TokenizeCharacters(text string) []Token {
+	var output []Token
+	spaceBefore := false
+	breaks := 0
+	state := -1
+	for len(text) > 0 {
+		var character string
+		character, text, _, state = uniseg.FirstGraphemeClusterInString(text, state)
+		if isBlank(character) {
+			spaceBefore = true
+			breaks += lineBreaks(character)
+			continue
+		}
+		output = appendLayout(output, breaks)
+		output = append(output, NewToken(character, spaceBefore))
+		spaceBefore = false
+		breaks = 0
+	}
+	return output
+}
@@ -4,10 +4,10 @@ package interfaceLanguage
 // Stores the translated interace in various languages.
 
 var InterfaceLanguage [][]string = [][]string{
-	{"What language do you want to study?", "Press q to quit.", "You are currently studying: ", "What text file do you want to open?", "\nPress f to make a dictionary file.\n", "Press b to go back to the language selection menu.\n", "Hello you are in ", " and cursor is at: ", "Current size: ", "Pages: ", "Translation of the selected word: ", "Error flag: ", "To go back to the main menu, press 'b' || Press f to make a dictionary file.", "Current romanization: "},
-	{"Che lingua vuoi studiare?", "Premere q per uscire.", "Stai studiando: ", "Che file di testo vuoi aprire?", "\nPremere 'f' per creare un file da esportare in flashcards.\n", "Premere 'b' per tornare al menu di selezione lingua.\n", "Sei correntemente in ", " e il cursore è alla posizione: ", "Dimensione attuale: ", "Pagine: ", "Traduzione della parola selezionata: ", "Errori: ", "Per tornare al menu principale, premere 'b' || Premere f per creare un flashcard file.", "Latinizzazione: "},
-	{"Quelle langue voulez-vous étudier?", "Appuyez sur la touche 'q' pour quitter.", "Vous étudiez maintenant: ", "Quel text-file voules-vouz ouvrir?", "\nAppuyez sur la touche 'f' pour créer une file pour le flashcards.\n", "Appuyez sur la touche 'b' pour retourner à le menu pour la selection d'une langue", "Vous êtes maintenant dans ", " et le curseur est à la position: ", "Dimension actuelle: ", "Pages: ", "Traduction de le mot sélectionné: ", "Erreurs: ", "Pour tourner à le menu principal, appuyez sur la touche 'b' || Appuyez sur la touche 'f' pour créer une file pour le flashcards.", "Latinisation: "},
-	{"¿Qué idioma quieres estudiar?", "Pulse 'q' para salir del programa.", "Actualmente estás estudiando: ", "¿Qué text-file quieres abrir?", "\nPulsa 'f' para crear un file para flashcards.\n", "Prensa 'b' para volver al menú de selección de idioma.", "Actualmente te encuentras en ", " y el cursor está en la posición: ", "Dimensiones actuales: ", "Paginas: ", "Traducción de la palabra seleccionada: ", "Errores: ", "Para volver a le menu principal, pulse 'b' || Pulsa 'f' para crear un file para flashcards. ", "Latinización: "},
+	{"What language do you want to study?", "Press q to quit.", "You are currently studying: ", "What text file do you want to open?", "\nPress f to make a dictionary file.\n", "Press b to go back to the language selection menu.\n", "Hello you are in ", " and cursor is at: ", "Current size: ", "Pages: ", "Translation of the selected word: ", "Error flag: ", "To go back to the main menu, press 'b' || Press f to make a dictionary file.", "Current romanization: ", "Press r to review the words you are learning.\n", "Words to review: ", "Press space to show the answer.", "How well did you remember it? 1) again 2) hard 3) good 4) easy", "There are no words to review right now.", "Press b to go back to the text selection menu.", "The tokenization server of this language is not reachable at ", "Start it with this command (from the src folder), then open the text again: ", "Tokenization server: ", "starting (the first time it can take a while)...", "ready", "it crashed too many times, LinGo gave up: ", "Press L to see the log of the tokenization server.\n", "Log of the tokenization server (press b to go back):", "stopped", "Selected phrase: ", "Press v to select a phrase (many words) and the keys 0-9 act on the whole phrase; press v again (or esc) to stop selecting.", "Sentence: ", "Translation of the sentence: ", "Press s to translate the sentence around the cursor (S for the alternative translation).", "Dictionaries", "No dictionary found: put your dictionaries (StarDict, dictd or .tsv) in ", "No entry for this word in the dictionaries.", "Press i to show (or hide) the dictionaries, J and K to scroll them.", "Getting the pronunciation... (esc to stop)", "Translating... (esc to stop)", "Latinizing... (esc to stop)", "Translating the sentence... (esc to stop)", " (pinned)", "Press p to pin the translation shown to the word (it will always be used for it, also in the dictionary files), P to unpin it.", "Making the dictionary file of ", "Words done: ", "The dictionary file was saved in ", "Words in the file: ", "Words that couldn't be translated (they aren't in the file: make it again to retry them):", "The full list is in ", "Stopping the export...", "The export was stopped: the dictionary file wasn't changed.", "Press esc to stop the export.", "Press enter (or b) to go back.", "Alternative translations (mymemory) of ", "j/k to choose, enter to save it as the meaning of the word, esc to close.", "No alternative translation found.", "Getting the alternative translations... (esc to stop)", "Press A to list the alternative translations of the word (from mymemory) and choose the one to save.", "Your meaning: ", "Note: ", "Your meaning of ", "tab to switch between the meaning and the note, enter to save, esc to cancel (save an empty meaning to go back to the translations).", "Press e to write your own meaning of the word (and a note): it's used instead of the translations everywhere.", "the meaning of the word", "a note (optional)", "Loading the offline dictionaries... (esc to stop)", "Opening the text as soon as the tokenization server is ready... (esc to stop)"},
+	{"Che lingua vuoi studiare?", "Premere q per uscire.", "Stai studiando: ", "Che file di testo vuoi aprire?", "\nPremere 'f' per creare un file da esportare in flashcards.\n", "Premere 'b' per tornare al menu di selezione lingua.\n", "Sei correntemente in ", " e il cursore è alla posizione: ", "Dimensione attuale: ", "Pagine: ", "Traduzione della parola selezionata: ", "Errori: ", "Per tornare al menu principale, premere 'b' || Premere f per creare un flashcard file.", "Latinizzazione: ", "Premere 'r' per ripassare le parole che stai imparando.\n", "Parole da ripassare: ", "Premere lo spazio per vedere la risposta.", "Quanto bene la ricordavi? 1) di nuovo 2) difficile 3) bene 4) facile", "Non ci sono parole da ripassare al momento.", "Premere 'b' per tornare al menu dei testi.", "Il server di tokenizzazione di questa lingua non è raggiungibile a ", "Avvialo con questo comando (dalla cartella src), poi riapri il testo: ", "Server di tokenizzazione: ", "in avvio (la prima volta può volerci un po')...", "pronto", "si è bloccato troppe volte, LinGo ci ha rinunciato: ", "Premere 'L' per vedere il log del server di tokenizzazione.\n", "Log del server di tokenizzazione (premere 'b' per tornare indietro):", "fermo", "Frase selezionata: ", "Premere 'v' per selezionare una frase (più parole) e i tasti 0-9 agiranno sull'intera frase; premere di nuovo 'v' (o esc) per smettere di selezionare.", "Frase: ", "Traduzione della frase: ", "Premere 's' per tradurre la frase attorno al cursore ('S' per la traduzione alternativa).", "Dizionari", "Nessun dizionario trovato: metti i tuoi dizionari (StarDict, dictd o .tsv) in ", "Nessuna voce per questa parola nei dizionari.", "Premi i per mostrare (o nascondere) i dizionari, J e K per scorrerli.", "Recupero la pronuncia... (esc per fermare)", "Traduco... (esc per fermare)", "Traslittero... (esc per fermare)", "Traduco la frase... (esc per fermare)", " (fissata)", "Premi p per fissare la traduzione mostrata alla parola (verrà sempre usata, anche nei file dizionario), P per sbloccarla.", "Creazione del file dizionario di ", "Parole fatte: ", "Il file dizionario è stato salvato in ", "Parole nel file: ", "Parole che non è stato possibile tradurre (non sono nel file: crealo di nuovo per riprovare):", "La lista completa è in ", "Interruzione dell'esportazione...", "L'esportazione è stata interrotta: il file dizionario non è stato modificato.", "Premi esc per interrompere l'esportazione.", "Premi invio (o b) per tornare indietro.", "Traduzioni alternative (mymemory) di ", "j/k per scegliere, invio per salvarla come significato della parola, esc per chiudere.", "Nessuna traduzione alternativa trovata.", "Ricerca delle traduzioni alternative... (esc per interrompere)", "Premi A per elencare le traduzioni alternative della parola (da mymemory) e scegliere quella da salvare.", "Il tuo significato: ", "Nota: ", "Il tuo significato di ", "tab per passare dal significato alla nota, invio per salvare, esc per annullare (salva un significato vuoto per tornare alle traduzioni).", "Premi e per scrivere il tuo significato della parola (e una nota): viene usato al posto delle traduzioni ovunque.", "il significato della parola", "una nota (facoltativa)", "Carico i dizionari offline... (esc per fermare)", "Apro il testo appena il server di tokenizzazione è pronto... (esc per fermare)"},
+	{"Quelle langue voulez-vous étudier?", "Appuyez sur la touche 'q' pour quitter.", "Vous étudiez maintenant: ", "Quel text-file voules-vouz ouvrir?", "\nAppuyez sur la touche 'f' pour créer une file pour le flashcards.\n", "Appuyez sur la touche 'b' pour retourner à le menu pour la selection d'une langue", "Vous êtes maintenant dans ", " et le curseur est à la position: ", "Dimension actuelle: ", "Pages: ", "Traduction de le mot sélectionné: ", "Erreurs: ", "Pour tourner à le menu principal, appuyez sur la touche 'b' || Appuyez sur la touche 'f' pour créer une file pour le flashcards.", "Latinisation: ", "Appuyez sur la touche 'r' pour réviser les mots que vous apprenez.\n", "Mots à réviser: ", "Appuyez sur la touche espace pour voir la réponse.", "Vous vous en souveniez? 1) à revoir 2) difficile 3) bien 4) facile", "Il n'y a pas de mots à réviser pour le moment.", "Appuyez sur la touche 'b' pour retourner au menu des textes.", "Le serveur de tokenisation de cette langue n'est pas joignable à ", "Lancez-le avec cette commande (depuis le dossier src), puis rouvrez le texte: ", "Serveur de tokenisation: ", "en cours de démarrage (la première fois cela peut prendre un moment)...", "prêt", "il a planté trop de fois, LinGo a abandonné: ", "Appuyez sur la touche 'L' pour voir le journal du serveur de tokenisation.\n", "Journal du serveur de tokenisation (appuyez sur la touche 'b' pour revenir):", "arrêté", "Expression sélectionnée: ", "Appuyez sur la touche 'v' pour sélectionner une expression (plusieurs mots) et les touches 0-9 agiront sur toute l'expression; appuyez encore sur 'v' (ou esc) pour arrêter la sélection.", "Phrase: ", "Traduction de la phrase: ", "Appuyez sur la touche 's' pour traduire la phrase autour du curseur ('S' pour la traduction alternative).", "Dictionnaires", "Aucun dictionnaire trouvé : mettez vos dictionnaires (StarDict, dictd ou .tsv) dans ", "Aucune entrée pour ce mot dans les dictionnaires.", "Appuyez sur i pour afficher (ou masquer) les dictionnaires, J et K pour les faire défiler.", "Récupération de la prononciation... (esc pour arrêter)", "Traduction... (esc pour arrêter)", "Translittération... (esc pour arrêter)", "Traduction de la phrase... (esc pour arrêter)", " (épinglée)", "Appuie sur p pour épingler la traduction affichée au mot (elle sera toujours utilisée, aussi dans les fichiers dictionnaire), P pour la désépingler.", "Création du fichier dictionnaire de ", "Mots traités : ", "Le fichier dictionnaire a été enregistré dans ", "Mots dans le fichier : ", "Mots qui n'ont pas pu être traduits (ils ne sont pas dans le fichier : recrée-le pour réessayer) :", "La liste complète est dans ", "Arrêt de l'export...", "L'export a été arrêté : le fichier dictionnaire n'a pas été modifié.", "Appuie sur esc pour arrêter l'export.", "Appuie sur entrée (ou b) pour revenir en arrière.", "Traductions alternatives (mymemory) de ", "j/k pour choisir, entrée pour l'enregistrer comme sens du mot, esc pour fermer.", "Aucune traduction alternative trouvée.", "Recherche des traductions alternatives... (esc pour arrêter)", "Appuie sur A pour lister les traductions alternatives du mot (de mymemory) et choisir celle à enregistrer.", "Ton sens : ", "Note : ", "Ton sens de ", "tab pour passer du sens à la note, entrée pour enregistrer, esc pour annuler (enregistre un sens vide pour revenir aux traductions).", "Appuie sur e pour écrire ton propre sens du mot (et une note) : il remplace les traductions partout.", "le sens du mot", "une note (facultative)", "Chargement des dictionnaires hors ligne... (esc pour arrêter)", "Ouverture du texte dès que le serveur de tokenisation est prêt... (esc pour arrêter)"},
+	{"¿Qué idioma quieres estudiar?", "Pulse 'q' para salir del programa.", "Actualmente estás estudiando: ", "¿Qué text-file quieres abrir?", "\nPulsa 'f' para crear un file para flashcards.\n", "Prensa 'b' para volver al menú de selección de idioma.", "Actualmente te encuentras en ", " y el cursor está en la posición: ", "Dimensiones actuales: ", "Paginas: ", "Traducción de la palabra seleccionada: ", "Errores: ", "Para volver a le menu principal, pulse 'b' || Pulsa 'f' para crear un file para flashcards. ", "Latinización: ", "Pulsa 'r' para repasar las palabras que estás aprendiendo.\n", "Palabras para repasar: ", "Pulsa la barra espaciadora para ver la respuesta.", "¿Qué tal la recordabas? 1) otra vez 2) difícil 3) bien 4) fácil", "No hay palabras para repasar por ahora.", "Pulsa 'b' para volver al menú de textos.", "El servidor de tokenización de este idioma no está disponible en ", "Inícialo con este comando (desde la carpeta src) y vuelve a abrir el texto: ", "Servidor de tokenización: ", "iniciándose (la primera vez puede tardar un poco)...", "listo", "se ha caído demasiadas veces, LinGo se ha rendido: ", "Pulsa 'L' para ver el registro del servidor de tokenización.\n", "Registro del servidor de tokenización (pulsa 'b' para volver):", "detenido", "Expresión seleccionada: ", "Pulsa 'v' para seleccionar una expresión (varias palabras) y las teclas 0-9 actuarán sobre toda la expresión; pulsa 'v' otra vez (o esc) para dejar de seleccionar.", "Oración: ", "Traducción de la oración: ", "Pulsa 's' para traducir la oración alrededor del cursor ('S' para la traducción alternativa).", "Diccionarios", "No se encontró ningún diccionario: pon tus diccionarios (StarDict, dictd o .tsv) en ", "No hay ninguna entrada para esta palabra en los diccionarios.", "Pulsa i para mostrar (u ocultar) los diccionarios, J y K para desplazarlos.", "Obteniendo la pronunciación... (esc para detener)", "Traduciendo... (esc para detener)", "Transliterando... (esc para detener)", "Traduciendo la frase... (esc para detener)", " (fijada)", "Pulsa p para fijar la traducción mostrada a la palabra (se usará siempre, también en los archivos de diccionario), P para quitarla.", "Creando el archivo de diccionario de ", "Palabras hechas: ", "El archivo de diccionario se guardó en ", "Palabras en el archivo: ", "Palabras que no se pudieron traducir (no están en el archivo: créalo de nuevo para reintentarlo):", "La lista completa está en ", "Deteniendo la exportación...", "La exportación se detuvo: el archivo de diccionario no se modificó.", "Pulsa esc para detener la exportación.", "Pulsa enter (o b) para volver.", "Traducciones alternativas (mymemory) de ", "j/k para elegir, enter para guardarla como significado de la palabra, esc para cerrar.", "No se encontró ninguna traducción alternativa.", "Buscando las traducciones alternativas... (esc para detener)", "Pulsa A para ver las traducciones alternativas de la palabra (de mymemory) y elegir la que quieres guardar.", "Tu significado: ", "Nota: ", "Tu significado de ", "tab para pasar del significado a la nota, enter para guardar, esc para cancelar (guarda un significado vacío para volver a las traducciones).", "Pulsa e para escribir tu propio significado de la palabra (y una nota): se usa en lugar de las traducciones en todas partes.", "el significado de la palabra", "una nota (opcional)", "Cargando los diccionarios sin conexión... (esc para detener)", "Abriendo el texto en cuanto el servidor de tokenización esté listo... (esc para detener)"},
 	{"Welche Sprache möchtest du lernen?",
 		"Drücke 'q', um das Programm zu beenden.",
 		"Du lernst derzeit: ",
@@ -21,7 +21,61 @@ var InterfaceLanguage [][]string = [][]string{
 		"Übersetzung des ausgewählten Worts: ",
 		"Fehler: ",
 		"Um zum Hauptmenü zurückzukehren, drücke 'b' || Drücke 'f', um eine Datei für Lernkarten zu erstellen.",
-		"Latinisierung: "},
+		"Latinisierung: ",
+		"Drücke 'r', um die Wörter zu wiederholen, die du lernst.\n",
+		"Wörter zum Wiederholen: ",
+		"Drücke die Leertaste, um die Antwort zu sehen.",
+		"Wie gut hast du dich erinnert? 1) nochmal 2) schwer 3) gut 4) leicht",
+		"Im Moment gibt es keine Wörter zum Wiederholen.",
+		"Drücke 'b', um zum Textmenü zurückzukehren.",
+		"Der Tokenisierungsserver dieser Sprache ist nicht erreichbar unter ",
+		"Starte ihn mit diesem Befehl (im Ordner src) und öffne den Text erneut: ",
+		"Tokenisierungsserver: ",
+		"wird gestartet (beim ersten Mal kann es etwas dauern)...",
+		"bereit",
+		"er ist zu oft abgestürzt, LinGo hat aufgegeben: ",
+		"Drücke 'L', um das Protokoll des Tokenisierungsservers zu sehen.\n",
+		"Protokoll des Tokenisierungsservers (drücke 'b', um zurückzukehren):",
+		"gestoppt",
+		"Ausgewählter Ausdruck: ",
+		"Drücke 'v', um einen Ausdruck (mehrere Wörter) auszuwählen, dann wirken die Tasten 0-9 auf den ganzen Ausdruck; drücke erneut 'v' (oder Esc), um die Auswahl zu beenden.",
+		"Satz: ",
+		"Übersetzung des Satzes: ",
+		"Drücke 's', um den Satz um den Cursor zu übersetzen ('S' für die alternative Übersetzung).",
+		"Wörterbücher",
+		"Kein Wörterbuch gefunden: Lege deine Wörterbücher (StarDict, dictd oder .tsv) in ",
+		"Kein Eintrag für dieses Wort in den Wörterbüchern.",
+		"Drücke i, um die Wörterbücher ein- (oder aus-)zublenden, J und K, um sie zu scrollen.",
+		"Aussprache wird geladen... (esc zum Abbrechen)",
+		"Wird übersetzt... (esc zum Abbrechen)",
+		"Wird transliteriert... (esc zum Abbrechen)",
+		"Satz wird übersetzt... (esc zum Abbrechen)",
+		" (angeheftet)",
+		"Drücke p, um die angezeigte Übersetzung an das Wort zu heften (sie wird immer verwendet, auch in den Wörterbuchdateien), P, um sie zu lösen.",
+		"Erstelle die Wörterbuchdatei für ",
+		"Erledigte Wörter: ",
+		"Die Wörterbuchdatei wurde gespeichert in ",
+		"Wörter in der Datei: ",
+		"Wörter, die nicht übersetzt werden konnten (sie sind nicht in der Datei: erstelle sie erneut, um es nochmal zu versuchen):",
+		"Die vollständige Liste ist in ",
+		"Export wird gestoppt...",
+		"Der Export wurde gestoppt: die Wörterbuchdatei wurde nicht geändert.",
+		"Drücke esc, um den Export zu stoppen.",
+		"Drücke Enter (oder b), um zurückzugehen.",
+		"Alternative Übersetzungen (mymemory) von ",
+		"j/k zum Auswählen, Enter, um sie als Bedeutung des Wortes zu speichern, esc zum Schließen.",
+		"Keine alternative Übersetzung gefunden.",
+		"Suche alternative Übersetzungen... (esc zum Stoppen)",
+		"Drücke A, um die alternativen Übersetzungen des Wortes (von mymemory) aufzulisten und die zu speichernde auszuwählen.",
+		"Deine Bedeutung: ",
+		"Notiz: ",
+		"Deine Bedeutung von ",
+		"Tab wechselt zwischen Bedeutung und Notiz, Enter speichert, esc bricht ab (speichere eine leere Bedeutung, um zu den Übersetzungen zurückzukehren).",
+		"Drücke e, um deine eigene Bedeutung des Wortes (und eine Notiz) zu schreiben: sie ersetzt überall die Übersetzungen.",
+		"die Bedeutung des Wortes",
+		"eine Notiz (optional)",
+		"Offline-Wörterbücher werden geladen... (esc zum Abbrechen)",
+		"Der Text wird geöffnet, sobald der Tokenisierungsserver bereit ist... (esc zum Abbrechen)"},
 	{"Какой язык вы хотите изучать?",
 		"Нажмите 'q', чтобы выйти из программы.",
 		"Сейчас изучаете: ",
@@ -35,7 +89,61 @@ var InterfaceLanguage [][]string = [][]string{
 		"Перевод выбранного слова: ",
 		"Ошибки и исключения: ",
 		"Для возврата в главное меню нажмите 'b' || Нажмите 'f', чтобы создать файл для флашкарды.",
-		"Латинизация: "},
+		"Латинизация: ",
+		"Нажмите 'r', чтобы повторить слова, которые вы изучаете.\n",
+		"Слова для повторения: ",
+		"Нажмите пробел, чтобы увидеть ответ.",
+		"Насколько хорошо вы помнили? 1) снова 2) трудно 3) хорошо 4) легко",
+		"Сейчас нет слов для повторения.",
+		"Нажмите 'b', чтобы вернуться в меню текстов.",
+		"Сервер токенизации для этого языка недоступен по адресу ",
+		"Запустите его этой командой (из папки src) и снова откройте текст: ",
+		"Сервер токенизации: ",
+		"запускается (в первый раз это может занять некоторое время)...",
+		"готов",
+		"он слишком часто падал, LinGo сдался: ",
+		"Нажмите 'L', чтобы увидеть журнал сервера токенизации.\n",
+		"Журнал сервера токенизации (нажмите 'b', чтобы вернуться):",
+		"остановлен",
+		"Выбранное выражение: ",
+		"Нажмите 'v', чтобы выделить выражение (несколько слов), и клавиши 0-9 будут действовать на всё выражение; нажмите 'v' ещё раз (или esc), чтобы закончить выделение.",
+		"Предложение: ",
+		"Перевод предложения: ",
+		"Нажмите 's', чтобы перевести предложение вокруг курсора ('S' для альтернативного перевода).",
+		"Словари",
+		"Словари не найдены: положите свои словари (StarDict, dictd или .tsv) в ",
+		"В словарях нет статьи для этого слова.",
+		"Нажмите i, чтобы показать (или скрыть) словари, J и K, чтобы прокрутить их.",
+		"Загружаю произношение... (esc, чтобы остановить)",
+		"Перевожу... (esc, чтобы остановить)",
+		"Транслитерирую... (esc, чтобы остановить)",
+		"Перевожу предложение... (esc, чтобы остановить)",
+		" (закреплён)",
+		"Нажмите p, чтобы закрепить показанный перевод за словом (он будет использоваться всегда, и в файлах словаря), P, чтобы открепить его.",
+		"Создание файла словаря для языка ",
+		"Готово слов: ",
+		"Файл словаря сохранён в ",
+		"Слов в файле: ",
+		"Слова, которые не удалось перевести (их нет в файле: создайте его снова, чтобы повторить попытку):",
+		"Полный список в ",
+		"Остановка экспорта...",
+		"Экспорт остановлен: файл словаря не изменён.",
+		"Нажмите esc, чтобы остановить экспорт.",
+		"Нажмите enter (или b), чтобы вернуться назад.",
+		"Альтернативные переводы (mymemory) для ",
+		"j/k — выбрать, enter — сохранить как значение слова, esc — закрыть.",
+		"Альтернативных переводов не найдено.",
+		"Поиск альтернативных переводов... (esc — остановить)",
+		"Нажмите A, чтобы увидеть альтернативные переводы слова (из mymemory) и выбрать тот, который нужно сохранить.",
+		"Ваше значение: ",
+		"Заметка: ",
+		"Ваше значение для ",
+		"tab — переключение между значением и заметкой, enter — сохранить, esc — отменить (сохраните пустое значение, чтобы вернуться к переводам).",
+		"Нажмите e, чтобы написать своё значение слова (и заметку): оно везде используется вместо переводов.",
+		"значение слова",
+		"заметка (необязательно)",
+		"Загрузка офлайн-словарей... (esc — остановить)",
+		"Текст откроется, как только сервер токенизации будет готов... (esc — остановить)"},
 }
 
 // LanguagesCodeMap map:
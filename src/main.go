@@ -6,7 +6,8 @@ package main
 // Imports:
 
 /*
-1) fmt --> for printing, formatting exc.
+1) fmt --> for printing, formatting exc. (and errors, to find out what kind of error we got); context --> to stop
+the lookups of the reader (translations, audio exc.) that take too long or that we don't need anymore
 2) io/ioutil and os --> working with files
 3) path/filepath --> used to list the number of subdirectories and files inside directories.
 4) strings --> used for some string methods used throughout the program
@@ -17,20 +18,32 @@ the files inside will explain what each package does.
 */
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"example.com/packages/audioPlayer"
+	"example.com/packages/config"
+	"example.com/packages/dictionary"
 	"example.com/packages/fileReader"
 	"example.com/packages/interfaceLanguage"
 	"example.com/packages/languageHandler"
+	"example.com/packages/serverManager"
+	"example.com/packages/spacedRepetition"
 	"example.com/packages/strokeOrder"
 	"example.com/packages/terminalSize"
 	"example.com/packages/translator"
+	"example.com/packages/vocabulary"
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/charmbracelet/lipgloss"
@@ -44,7 +57,7 @@ var (
 	titleStyle = lipgloss.NewStyle().MarginLeft(2)
 	itemStyle  = lipgloss.NewStyle().PaddingLeft(4)
 	// Style for the current item we're hovering over in a text.
-	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(lipgloss.Color("170"))
+	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(fileReader.CursorPadding).Foreground(lipgloss.Color("170"))
 	// This is the style for the "quit text", i.e the text that tells us how to quit the program.
 	quitTextStyle = lipgloss.NewStyle().Margin(1, 0, 2, 4)
 	// These are the styles for all the "word levels"
@@ -54,6 +67,14 @@ var (
 	semiKnownItemStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFCA3A"))
 	// 3) Known --> word will become green
 	knownItemStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#00b300"))
+	// Style for the words of the phrase we're selecting (see the "v" key in the reader).
+	selectionStyle = lipgloss.NewStyle().Background(lipgloss.Color("238"))
+	// Style for the word under the cursor (and its translation) in the translation of the sentence.
+	alignedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Underline(true)
+	// The style of the panel of the offline dictionaries, and of the headwords in it
+	dictionaryPanelStyle = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderLeft(true).BorderForeground(lipgloss.Color("240")).PaddingLeft(1)
+	headwordStyle        = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("170"))
+	tagStyle             = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
 )
 
 // These are the styles for the tables used in the menus
@@ -112,7 +133,7 @@ type model struct {
 	choices         []string        // text-file selection menu (OLD)
 	choices2        []string        // language select menu (OLD)
 	cursor          int             // which to-do list item our cursor is pointing at
-	viewIndex       int             // viewIndex --> will be 0 for the menu, and 1 for an opened file.
+	viewIndex       int             // viewIndex --> will be 0 for the menu, 1 for an opened file, 2 for the language menu, 3 for a review session, 4 for the log of the tokenization server and 5 for the export of a dictionary file.
 	openedFile      string          // will store the name of the file we opened.
 	openedFileText  fileReader.Text // will store the fileReader.Text object representing the file we opened
 	cursor2         int             //
@@ -123,6 +144,71 @@ type model struct {
 	languageTable table.Model         // This is the table listing all the languages (new UI)
 	textTable     table.Model         // This is the table listing all the text files we can open inside the app (new UI).
 	hanziData     map[string][]string // This is the map that stores the pinyin equivalent of the most common hanzi in simplified mandarin chinese
+	width         int                 // The width of the terminal (updated every time the terminal is resized)
+	// serverDown is set when the tokenization server of the current language can't be reached.
+	serverDown *fileReader.ServerDownError
+	// waitingText is the text we open as soon as the tokenization server LinGo is starting is ready (see serverWaitMsg).
+	waitingText string
+	// server is the tokenization server LinGo started for the current language (nil if there's none),
+	// and serverLog the last lines of its log (viewIndex 4).
+	server    *serverManager.Server
+	serverLog []string
+	// selecting is true while we select a phrase in the reader: the phrase goes from selectionAnchor to the cursor.
+	selecting       bool
+	selectionAnchor int
+	// sentenceTranslation is the translation of the sentence we asked for with s/S in the reader,
+	// and the sentence goes from the token sentenceStart to sentenceEnd (excluded).
+	sentenceTranslation *translator.SentenceTranslation
+	sentenceStart       int
+	sentenceEnd         int
+	// dictionaryOpen is true when the reader shows the panel of the offline dictionaries (key i), with the
+	// entries of the word (or phrase) dictionaryKey, scrolled down by dictionaryScroll lines. dictionaryLibrary
+	// holds the dictionaries of dictionaryLanguage, once they are loaded (see lookupDictionary).
+	dictionaryOpen     bool
+	dictionaryKey      string
+	dictionaryEntries  []dictionary.Entry
+	dictionaryScroll   int
+	dictionaryLibrary  *dictionary.Library
+	dictionaryLanguage string
+	// lookupKind is what the reader (or the review) is waiting for ("audio", "translation", "review"... see lookupMessages
+	// and startLookup), or "" if nothing; lookupCancel stops it, and lookupID tells its result apart from the ones of the lookups we cancelled.
+	lookupKind   string
+	lookupID     int
+	lookupCancel context.CancelFunc
+	spinner      spinner.Model // Shown while we wait for a lookup
+	// translationKey is the word (or phrase) the translation shown by the reader (CurrentTranslate) belongs to.
+	translationKey string
+	// alternatives are the translations of the word alternativesText (whose key is alternativesKey) found by mymemory
+	// (key A), ranked by their score, and alternativeCursor the one we're choosing; while they're shown (non nil),
+	// the keys of the reader move in the list.
+	alternatives      []translator.Match
+	alternativesText  string
+	alternativesKey   string
+	alternativeCursor int
+	// editing is true while we write our own meaning of the word editText (whose key is editKey) and a note
+	// about it (key e), in meaningInput and noteInput; the keys of the reader go to the inputs meanwhile.
+	editing      bool
+	editText     string
+	editKey      string
+	meaningInput textinput.Model
+	noteInput    textinput.Model
+	height       int // The height of the terminal
+	// These are the fields used by the review session (viewIndex 3).
+	reviewDeck     *spacedRepetition.Deck // The scheduling data of the words of the current language
+	reviewWords    *vocabulary.Store      // The vocabulary of the current language
+	reviewQueue    []string               // The words we still have to review in this session
+	reviewRevealed bool                   // Whether the answer for the current word is being shown
+	reviewAnswer   string                 // The translation (and latinization) of the current word
+	// These are the fields used by the export of the dictionary files (viewIndex 5, see startExport).
+	exportBar      progress.Model
+	exportReturn   int                // The view we go back to when the export is over
+	exportUpdates  <-chan tea.Msg     // The progress of the export
+	exportCancel   context.CancelFunc // Stops the export (nil when it's over)
+	exportStopping bool
+	exportDone     int
+	exportTotal    int
+	exportResult   *fileReader.ExportResult // What happened (nil until the export is over)
+	exportErr      error
 }
 
 // This function initializes the bubbletea model to boot the application; this is one of the "dirtiest" parts of the application
@@ -199,17 +285,30 @@ func initialModel() model {
 
 	t2.SetStyles(s)
 
+	// Load the settings in setup/config.json; if something is wrong with them, let the user know.
+	currentError := ""
+	if _, err := config.Load(); err != nil {
+		currentError = fmt.Sprintf("%s: %s", config.Path, err.Error())
+	}
+
+	// Get the size of the terminal once; then bubbletea tells us when it changes.
+	width, height := terminalSize.GetTerminalSize()
+
 	// return the model object we need to start the bubbletea app.
 	return model{
 		choices:       filePaths,
 		choices2:      directories,
 		viewIndex:     2,
 		cursor2:       0,
-		currentError:  "",
+		currentError:  currentError,
 		bootLanguage:  bootLangString,
 		languageTable: t,
 		textTable:     t2,
 		hanziData:     translator.InitHanzi(),
+		spinner:       spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("170")))),
+		exportBar:     progress.New(progress.WithDefaultGradient()),
+		width:         width,
+		height:        height,
 	}
 }
 
@@ -222,10 +321,62 @@ func (m model) Init() tea.Cmd {
 // when a key is pressed or when something is changed (in according with the ELM architecture principles).
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	m.openedFileText.PageList = fileReader.DivideInPages(m.openedFileText.TokenList)
-	m.openedFileText.Pages = len(m.openedFileText.PageList)
-	if m.openedFileText.CurrentPage > m.openedFileText.Pages-1 {
-		m.openedFileText.CurrentPage = 0
+	// When the terminal is resized, divide the opened text in pages again (keeping the cursor on the same word).
+	if msg, ok := msg.(tea.WindowSizeMsg); ok {
+		m.width, m.height = msg.Width, msg.Height
+		if m.viewIndex == 1 {
+			m.openedFileText.Paginate(m.textWidth(), m.height)
+		}
+	}
+	// When the tokenization server we started is ready (or failed), its new status is shown (and its log, if we are reading it).
+	if _, ok := msg.(serverStatusMsg); ok && m.viewIndex == 4 {
+		m.readServerLog()
+	}
+	// The results of the lookups we cancelled (or replaced with another one) are ignored.
+	if msg, ok := msg.(lookupResultMsg); ok {
+		if msg.id == m.lookupID && m.lookupKind != "" {
+			m.finishLookup(msg)
+			// The panel of the dictionaries waits for the lookups of the reader (and for the dictionaries to be loaded).
+			if m.viewIndex == 1 && m.dictionaryOpen {
+				return m, m.lookupDictionary()
+			}
+		}
+		return m, nil
+	}
+	// The export of a dictionary file tells us how it's going until it's over.
+	if msg, ok := msg.(exportProgressMsg); ok {
+		m.exportDone, m.exportTotal = msg.done, msg.total
+		return m, waitExport(m.exportUpdates)
+	}
+	if msg, ok := msg.(exportFinishedMsg); ok {
+		m.exportCancel = nil
+		m.exportResult, m.exportErr = &msg.result, msg.err
+		return m, nil
+	}
+	// The text we wanted to open is opened when the tokenization server is ready (if we're still in the menu).
+	if msg, ok := msg.(serverWaitMsg); ok {
+		if msg.filename != m.waitingText {
+			return m, nil
+		}
+		m.waitingText = ""
+		if msg.err != nil {
+			m.currentError = msg.err.Error()
+			return m, nil
+		}
+		if m.viewIndex == 0 {
+			text, err := fileReader.InitText(msg.filename, m.currentLanguage)
+			m.openText(msg.filename, text, err)
+		}
+		return m, nil
+	}
+	// The spinner moves only while we are waiting for a lookup (or for the tokenization server).
+	if msg, ok := msg.(spinner.TickMsg); ok {
+		if m.lookupKind == "" && m.waitingText == "" {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
 	}
 	m.languageTable, _ = m.languageTable.Update(msg)
 	m.textTable, _ = m.textTable.Update(msg)
@@ -261,56 +412,95 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// The "enter" key and the spacebar (a literal space) toggle
 			// the selected state for the item that the cursor is pointing at.
 			case "enter", " ":
-				m.viewIndex = 1
-				m.openedFile = "texts/" + m.textTable.SelectedRow()[0]
-				text := fileReader.InitText(m.openedFile, m.currentLanguage)
-				m.openedFileText = text
+				m.currentError = ""
+				m.serverDown = nil
+				m.waitingText = ""
+				filename := "texts/" + m.textTable.SelectedRow()[0]
+				text, err := fileReader.InitText(filename, m.currentLanguage)
+				// If the tokens of the text aren't cached and LinGo is starting the server of the language, the text
+				// is opened when the server is ready (see serverWaitMsg): the menu keeps working in the meantime.
+				if errors.As(err, &m.serverDown) && m.server != nil {
+					m.serverDown = nil
+					m.waitingText = filename
+					return m, tea.Batch(m.waitServer(filename), m.spinner.Tick)
+				}
+				m.openText(filename, text, err)
+
+			// Stop waiting for the tokenization server to open the text.
+			case "esc":
+				m.waitingText = ""
 			// If the key pressed is f, generate a dictionary file.
-			case "f":
+			case "f", "z":
 				dictionary := fileReader.MakeDictFromMenu(m.currentLanguage)
-				fileReader.MakeDictionary(dictionary, m.currentLanguage, m.bootLanguage)
-			case "z":
-				dictionary := fileReader.MakeDictFromMenu(m.currentLanguage)
-				fileReader.MakeAltDictionary(dictionary, m.currentLanguage, m.bootLanguage, m.hanziData)
+				return m, m.startExport(dictionary, msg.String() == "z")
+			// If the key pressed is r, start a review session of the words we marked with level 1 or 2.
+			case "r":
+				m.currentError = ""
+				m.reviewWords = fileReader.MakeDictFromMenu(m.currentLanguage)
+				deck, err := spacedRepetition.LoadDeck(m.currentLanguage)
+				if err != nil {
+					m.currentError = err.Error()
+				}
+				now := time.Now()
+				levels := m.reviewWords.Levels()
+				deck.Sync(levels, now)
+				m.reviewDeck = deck
+				m.reviewQueue = deck.DueWords(levels, now)
+				m.reviewRevealed = false
+				m.viewIndex = 3
+			// If the key pressed is L, show the log of the tokenization server of the language.
+			case "L":
+				m.readServerLog()
+				m.viewIndex = 4
 			}
 		}
 
 		// Return the updated model to the Bubble Tea runtime for processing.
 		// Note that we're not returning a command.
 	case 1:
+		if m.editing {
+			return m.updateEditor(msg)
+		}
 		switch msg := msg.(type) {
 
 		// Is it a key press?
 		case tea.KeyMsg:
+			if m.alternatives != nil {
+				return m.chooseAlternative(msg.String())
+			}
+			// The keys that need the network (or some time) start a lookup, which is run by this command.
+			var cmd tea.Cmd
 
 			// Cool, what was the actual key pressed?
 			switch msg.String() {
 
 			// These keys should exit the program.
 			case "ctrl+c", "q":
+				m.cancelLookup()
+				m.saveProgress()
 				return m, tea.Quit
 
-			// The "up" and "k" keys move the cursor up
+			// The "left" and "h" keys move the cursor to the previous word (punctuation is skipped)
 			case "left", "h":
-				if m.openedFileText.TokenCursorPosition > 0 {
-					m.openedFileText.TokenCursorPosition--
+				if previous := fileReader.WordAt(m.openedFileText.TokenList, m.openedFileText.TokenCursorPosition-1, -1); previous != -1 {
+					m.moveCursor(previous)
 				}
 
-			// The "down" and "j" keys move the cursor down
+			// The "right" and "l" keys move the cursor to the next word
 			case "right", "l":
-				if m.openedFileText.TokenCursorPosition < m.openedFileText.TokenLength-1 {
-					m.openedFileText.TokenCursorPosition++
+				if next := fileReader.WordAt(m.openedFileText.TokenList, m.openedFileText.TokenCursorPosition+1, 1); next != -1 {
+					m.moveCursor(next)
 				}
 
+			// The "up"/"k" and "down"/"j" keys move the cursor to the closest word on the previous/next line
+			// (the empty lines between paragraphs are skipped)
 			case "up", "k":
-				line := terminalSize.GetWordsPerLine()
-				if m.openedFileText.TokenCursorPosition > 0 && m.openedFileText.TokenCursorPosition-line >= 0 {
-					m.openedFileText.TokenCursorPosition -= line
+				if above := fileReader.VerticalMove(m.openedFileText.TokenList, m.openedFileText.PageList, m.openedFileText.TokenCursorPosition, -1); above != -1 {
+					m.moveCursor(above)
 				}
 			case "down", "j":
-				line := terminalSize.GetWordsPerLine()
-				if m.openedFileText.TokenCursorPosition < m.openedFileText.TokenLength-1 && m.openedFileText.TokenCursorPosition+line < m.openedFileText.TokenLength-1 {
-					m.openedFileText.TokenCursorPosition += line
+				if below := fileReader.VerticalMove(m.openedFileText.TokenList, m.openedFileText.PageList, m.openedFileText.TokenCursorPosition, 1); below != -1 {
+					m.moveCursor(below)
 				}
 
 			// We use a and d to move between pages
@@ -333,74 +523,143 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 
 			case "0":
-				m.openedFileText.WordLevels[m.openedFileText.TokenList[m.openedFileText.TokenCursorPosition]] = 0
-				fileReader.MakeJsonFile(m.openedFileText.WordLevels, m.currentLanguage)
+				m.setCurrentWordLevel(0)
 			case "1":
-				m.openedFileText.WordLevels[m.openedFileText.TokenList[m.openedFileText.TokenCursorPosition]] = 1
-				fileReader.MakeJsonFile(m.openedFileText.WordLevels, m.currentLanguage)
+				m.setCurrentWordLevel(1)
 			case "2":
-				m.openedFileText.WordLevels[m.openedFileText.TokenList[m.openedFileText.TokenCursorPosition]] = 2
-				fileReader.MakeJsonFile(m.openedFileText.WordLevels, m.currentLanguage)
+				m.setCurrentWordLevel(2)
 			case "3":
-				m.openedFileText.WordLevels[m.openedFileText.TokenList[m.openedFileText.TokenCursorPosition]] = 3
-				fileReader.MakeJsonFile(m.openedFileText.WordLevels, m.currentLanguage)
-
+				m.setCurrentWordLevel(3)
+
+			// The v key starts (or stops) the selection of a phrase: the keys 0-9 then act on the whole phrase.
+			case "v":
+				m.selecting = !m.selecting
+				m.selectionAnchor = m.openedFileText.TokenCursorPosition
+			// esc also stops the lookup we are waiting for (and the audio that is playing).
+			case "esc":
+				m.selecting = false
+				m.cancelLookup()
+
+			// The keys 4, 5, 6, 9 and s/S don't block the reader: the spinner turns until the result arrives.
 			case "4":
 				currentLanguageId := languageHandler.LanguageMap[m.currentLanguage]
+				itemText, itemKey := m.currentItem()
+				m.openedFileText.Words.RecordLookup(itemKey, "audio")
 				m.currentError = ""
-				m.currentError += audioPlayer.GetAudio(m.openedFileText.TokenList[m.openedFileText.TokenCursorPosition], currentLanguageId)
-				mp3FilePath := fmt.Sprintf("audio/%s.mp3", m.openedFileText.TokenList[m.openedFileText.TokenCursorPosition])
-
-				if m.currentError != "" {
-					m.currentError += "\n"
+				cmd = m.startLookup("audio", audioTimeout, func(ctx context.Context) lookupResultMsg {
+					return lookupResultMsg{errString: playAudio(ctx, itemText, currentLanguageId)}
+				})
+
+			// get translation (9 for the alternative one)
+			case "5", "9":
+				language, languageId, bootLanguage := m.currentLanguage, languageHandler.LanguageMap2[m.currentLanguage], m.bootLanguage
+				itemText, itemKey := m.currentItem()
+				m.openedFileText.Words.RecordLookup(itemKey, "translation")
+				alternative := msg.String() == "9"
+				// The meaning we wrote wins over the main translation (see the e key).
+				if entry, ok := m.openedFileText.Words.Lookup(itemKey); ok && entry.Meaning != "" && !alternative {
+					m.cancelLookup()
+					m.currentError = ""
+					m.openedFileText.CurrentTranslate, m.translationKey = entry.Meaning, itemKey
+					break
 				}
-				m.currentError += audioPlayer.PlayMP3(mp3FilePath)
-				m.currentError += "\n"
-				m.currentError += audioPlayer.DeleteMP3(mp3FilePath)
-
-			// get translation
-			case "5":
-				currentlLanguageId := languageHandler.LanguageMap2[m.currentLanguage]
-				translation, errString := translator.Translate2(m.openedFileText.TokenList[m.openedFileText.TokenCursorPosition], currentlLanguageId, m.bootLanguage)
-				m.currentError = errString
-				m.openedFileText.CurrentTranslate = translation
+				cmd = m.startLookup("translation", translationTimeout, func(ctx context.Context) lookupResultMsg {
+					translation, errString := translator.TranslateFor(ctx, language, itemText, languageId, bootLanguage, alternative)
+					return lookupResultMsg{result: translation, errString: errString}
+				})
+
+			// List the alternative translations of mymemory, to choose the one to save.
+			case "A":
+				languageId, bootLanguage := languageHandler.LanguageMap2[m.currentLanguage], m.bootLanguage
+				itemText, _ := m.currentItem()
+				cmd = m.startLookup("alternatives", translationTimeout, func(ctx context.Context) lookupResultMsg {
+					matches, errString := translator.TranslateMatches(ctx, itemText, languageId, bootLanguage)
+					return lookupResultMsg{alternatives: matches, text: itemText, errString: errString}
+				})
+
+			// Translate the whole sentence around the cursor (S for the alternative translation, like 9 does for words).
+			case "s", "S":
+				language, languageId, bootLanguage := m.currentLanguage, languageHandler.LanguageMap2[m.currentLanguage], m.bootLanguage
+				tokens := m.openedFileText.TokenList
+				start, end := fileReader.SentenceBounds(tokens, m.openedFileText.TokenCursorPosition)
+				sentence := fileReader.JoinTokens(tokens[start:end])
+				alternative := msg.String() == "S"
+				cmd = m.startLookup("sentence", translationTimeout, func(ctx context.Context) lookupResultMsg {
+					translation, errString := translator.TranslateSentence(ctx, language, sentence, languageId, bootLanguage, alternative)
+					return lookupResultMsg{sentence: &translation, sentenceStart: start, sentenceEnd: end, errString: errString}
+				})
 
 			case "6":
-				m.openedFileText.CurrentLatinization = translator.LatinizeText(m.openedFileText.TokenList[m.openedFileText.TokenCursorPosition], m.hanziData, m.currentLanguage)
+				words, language, hanziData := m.openedFileText.Words, m.currentLanguage, m.hanziData
+				_, itemKey := m.currentItem()
+				words.RecordLookup(itemKey, "latinization")
+				cmd = m.startLookup("latinization", latinizationTimeout, func(ctx context.Context) lookupResultMsg {
+					latinization, err := fileReader.LookupLatinization(ctx, words, itemKey, language, hanziData)
+					if err != nil {
+						return lookupResultMsg{errString: err.Error()}
+					}
+					return lookupResultMsg{result: latinization}
+				})
 			case "7":
-				url := fmt.Sprintf("https://www.strokeorder.com/chinese/%s", m.openedFileText.TokenList[m.openedFileText.TokenCursorPosition])
-				err := strokeOrder.OpenBrowser(url)
+				itemText, _ := m.currentItem()
+				link := fmt.Sprintf("https://www.strokeorder.com/chinese/%s", url.PathEscape(itemText))
+				err := strokeOrder.OpenBrowser(link)
 				if err != nil {
 					m.currentError += err.Error()
 				}
 			case "8":
-				url := fmt.Sprintf("https://translate.google.com/?sl=%s&tl=%s&text=%s&op=translate", languageHandler.LanguageMap2[m.currentLanguage], m.bootLanguage, m.openedFileText.TokenList[m.openedFileText.TokenCursorPosition])
-				err := strokeOrder.OpenBrowser(url)
+				itemText, _ := m.currentItem()
+				link := fmt.Sprintf("https://translate.google.com/?sl=%s&tl=%s&text=%s&op=translate", languageHandler.LanguageMap2[m.currentLanguage], m.bootLanguage, url.QueryEscape(itemText))
+				err := strokeOrder.OpenBrowser(link)
 				if err != nil {
 					m.currentError += err.Error()
 				}
-			case "9":
-				currentlLanguageId := languageHandler.LanguageMap2[m.currentLanguage]
-				translation, errString := translator.Translate(m.openedFileText.TokenList[m.openedFileText.TokenCursorPosition], currentlLanguageId, m.bootLanguage)
-				m.currentError = errString
-				m.openedFileText.CurrentTranslate = translation
+			case "f", "z":
+				m.cancelLookup()
+				cmd = m.startExport(m.openedFileText.Words, msg.String() == "z")
+
+			// Write our own meaning of the current word, and a note about it.
+			case "e":
+				m.cancelLookup()
+				cmd = m.startEditing()
 
-			case "f":
-				fileReader.MakeDictionary(m.openedFileText.WordLevels, m.currentLanguage, m.bootLanguage)
-			case "z":
-				fileReader.MakeAltDictionary(m.openedFileText.WordLevels, m.currentLanguage, m.bootLanguage, m.hanziData)
+			// Pin the translation shown to the current word (p), or unpin it (P), see translator/cache.go.
+			case "p", "P":
+				m.pinTranslation(msg.String() == "p")
 
 			// Move the cursor to the beginning of the current page.
 			case "m":
-				currentCursor := m.openedFileText.CurrentPage * terminalSize.GetLinesPerPage() * terminalSize.GetWordsPerLine()
-				m.openedFileText.TokenCursorPosition = currentCursor
+				currentCursor := m.openedFileText.PageList[m.openedFileText.CurrentPage].Start
+				if first := fileReader.WordAt(m.openedFileText.TokenList, currentCursor, 1); first != -1 {
+					m.moveCursor(first)
+				}
+
+			// The i key shows (or hides) the panel of the offline dictionaries, J and K scroll it.
+			case "i":
+				m.dictionaryOpen = !m.dictionaryOpen
+				m.dictionaryKey = ""
+				m.openedFileText.Paginate(m.textWidth(), m.height)
+			case "J":
+				if m.dictionaryScroll < len(m.dictionaryLines())-m.dictionaryHeight() {
+					m.dictionaryScroll++
+				}
+			case "K":
+				if m.dictionaryScroll > 0 {
+					m.dictionaryScroll--
+				}
 
-			// The "enter" key and the spacebar (a literal space) toggle
-			// the selected state for the item that the cursor is pointing at.
 			case "b":
+				m.cancelLookup()
+				m.saveProgress()
 				m.viewIndex = 0
+				m.selecting = false
 				m.currentError = ""
 			}
+			// The panel of the dictionaries follows the cursor.
+			if m.dictionaryOpen {
+				cmd = tea.Batch(cmd, m.lookupDictionary())
+			}
+			return m, cmd
 		}
 	case 2:
 		switch msg := msg.(type) {
@@ -426,21 +685,896 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "enter", " ":
 				m.viewIndex = 0
 				m.currentLanguage = m.languageTable.SelectedRow()[0]
+				cmd := m.checkTokenizer()
+				return m, cmd
+
+			}
+
+		}
+	case 3:
+		switch msg := msg.(type) {
+
+		// Is it a key press?
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "ctrl+c", "q":
+				return m, tea.Quit
+
+			// Show the answer for the current word (the translation and the latinization can take a request each).
+			case "enter", " ":
+				if len(m.reviewQueue) > 0 && !m.reviewRevealed && m.lookupKind == "" {
+					word, words, language, bootLanguage, hanziData := m.reviewQueue[0], m.reviewWords, m.currentLanguage, m.bootLanguage, m.hanziData
+					words.RecordLookup(word, "translation")
+					words.RecordLookup(word, "latinization")
+					return m, m.startLookup("review", translationTimeout+latinizationTimeout, func(ctx context.Context) lookupResultMsg {
+						answer := fileReader.LookupTranslation(ctx, words, word, language, bootLanguage)
+						latinization, err := fileReader.LookupLatinization(ctx, words, word, language, hanziData)
+						if err != nil {
+							// The translation is shown anyway.
+							return lookupResultMsg{result: answer, errString: err.Error()}
+						}
+						if latinization != "" && latinization != word {
+							answer += fmt.Sprintf(" (%s)", latinization)
+						}
+						return lookupResultMsg{result: answer}
+					})
+				}
+
+			// Stop waiting for the answer (enter asks for it again).
+			case "esc":
+				m.cancelLookup()
+
+			// Grade the current word: 1 --> again, 2 --> hard, 3 --> good, 4 --> easy.
+			case "1", "2", "3", "4":
+				if len(m.reviewQueue) == 0 || !m.reviewRevealed {
+					break
+				}
+				word := m.reviewQueue[0]
+				grade := int(msg.String()[0] - '1')
+				m.reviewQueue = m.reviewQueue[1:]
+				// If the word graduated, promote it to level 3 (know well) and save the new level.
+				if m.reviewDeck.Grade(word, grade, time.Now()) {
+					if err := m.reviewWords.SetLevel(word, 3); err != nil {
+						m.currentError = err.Error()
+					}
+				} else if grade == spacedRepetition.GradeAgain {
+					// If we didn't remember the word, we will see it again at the end of the session.
+					m.reviewQueue = append(m.reviewQueue, word)
+				}
+				if err := m.reviewDeck.Save(m.currentLanguage); err != nil {
+					m.currentError = err.Error()
+				}
+				m.reviewRevealed = false
+				m.reviewAnswer = ""
+
+			case "b":
+				m.cancelLookup()
+				m.viewIndex = 0
+				m.currentError = ""
+			}
+		}
+	case 4:
+		switch msg := msg.(type) {
+
+		// Is it a key press?
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "ctrl+c", "q":
+				return m, tea.Quit
+			case "b":
+				m.viewIndex = 0
+				m.currentError = ""
+			}
+		}
+	case 5:
+		switch msg := msg.(type) {
+
+		// Is it a key press?
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "ctrl+c", "q":
+				if m.exportCancel != nil {
+					m.exportCancel()
+				}
+				return m, tea.Quit
+			// esc stops the export; once it's over, enter (or b) goes back to where we were.
+			case "esc":
+				if m.exportCancel != nil {
+					m.exportCancel()
+					m.exportStopping = true
+				}
+			case "enter", "b":
+				if m.exportCancel == nil {
+					m.viewIndex = m.exportReturn
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// currentItem returns the text and the key (see vocabulary.Key) of what the keys of the reader act on:
+// the selected phrase while we are selecting one, the word under the cursor otherwise.
+func (m model) currentItem() (string, string) {
+	text := m.openedFileText
+	if m.selecting {
+		start, end := fileReader.Selection(m.selectionAnchor, text.TokenCursorPosition)
+		return fileReader.PhraseText(text.TokenList, start, end), fileReader.PhraseKey(text.TokenList, start, end)
+	}
+	token := text.CurrentToken()
+	return token.Text, token.Key
+}
 
+// setCurrentWordLevel sets the level of knowledge of the word under the cursor (or of the selected phrase), and records where we saw it.
+func (m *model) setCurrentWordLevel(level int) {
+	text := m.openedFileText
+	_, word := m.currentItem()
+	if word == "" {
+		return
+	}
+	// Once the phrase has its level, we're done selecting it.
+	m.selecting = false
+	m.currentError = ""
+	if err := text.Words.SetLevel(word, level); err != nil {
+		m.currentError = err.Error()
+		return
+	}
+	// The phrases shown in the pages may have changed with the level.
+	m.openedFileText.ForgetPhrases()
+	if err := text.Words.Encounter(word, text.Filename, fileReader.SentenceAt(text.TokenList, text.TokenCursorPosition)); err != nil {
+		m.currentError = err.Error()
+	}
+}
+
+// moveCursor moves the cursor on a word, and turns the page if the word is on another page;
+// the lookup we were waiting for (e.g the translation of the previous word) is cancelled.
+func (m *model) moveCursor(position int) {
+	if position != m.openedFileText.TokenCursorPosition {
+		m.cancelLookup()
+	}
+	m.openedFileText.TokenCursorPosition = position
+	m.openedFileText.CurrentPage = fileReader.PageOf(m.openedFileText.PageList, position)
+}
+
+// saveProgress stores the position of the cursor in the opened text, so that we can continue from there next time.
+func (m *model) saveProgress() {
+	text := m.openedFileText
+	if err := text.Words.SaveProgress(text.Filename, text.TokenCursorPosition); err != nil {
+		m.currentError = err.Error()
+	}
+}
+
+// saveTranslation stores the translation we just got for a word (or a phrase) in the vocabulary.
+func (m *model) saveTranslation(word string, translation string) {
+	if word == "" || translation == "" {
+		return
+	}
+	err := m.openedFileText.Words.Update(word, func(entry *vocabulary.Entry) {
+		entry.Translation = translation
+	})
+	if err != nil {
+		m.currentError = err.Error()
+	}
+}
+
+/*
+pinTranslation method:
+input: true to pin the translation shown to the current word, false to unpin the translation of the current word.
+The pinned translation is used for the word from then on, whatever the translation provider says (also in the
+dictionary files); it is stored in the vocabulary too, and removed from it when the translation is unpinned.
+*/
+
+func (m *model) pinTranslation(pin bool) {
+	itemText, itemKey := m.currentItem()
+	languageId := languageHandler.LanguageMap2[m.currentLanguage]
+	translation := ""
+	if pin {
+		// Only the translation of the current word can be pinned to it.
+		if m.translationKey != itemKey || m.openedFileText.CurrentTranslate == "" {
+			return
+		}
+		translation = m.openedFileText.CurrentTranslate
+	}
+	pinned, wasPinned := translator.Pinned(m.currentLanguage, itemText, languageId, m.bootLanguage)
+	err := translator.Pin(m.currentLanguage, itemText, languageId, m.bootLanguage, translation)
+	if err != nil {
+		m.currentError = err.Error()
+		return
+	}
+	m.currentError = ""
+	if pin {
+		m.saveTranslation(itemKey, translation)
+		return
+	}
+	// The vocabulary keeps the pinned translation: forget it, so that the provider is asked again.
+	if wasPinned {
+		err = m.openedFileText.Words.Update(itemKey, func(entry *vocabulary.Entry) {
+			if entry.Translation == pinned {
+				entry.Translation = ""
 			}
+		})
+		if err != nil {
+			m.currentError = err.Error()
+		}
+	}
+}
+
+// pinned tells if the translation shown by the reader is the one pinned to the current word.
+func (m model) pinned() bool {
+	itemText, itemKey := m.currentItem()
+	if m.translationKey != itemKey || m.openedFileText.CurrentTranslate == "" {
+		return false
+	}
+	translation, ok := translator.Pinned(m.currentLanguage, itemText, languageHandler.LanguageMap2[m.currentLanguage], m.bootLanguage)
+	return ok && translation == m.openedFileText.CurrentTranslate
+}
+
+// The longest time the lookups of the reader can take (for the audio, this includes the time to play it).
+const (
+	translationTimeout  = 20 * time.Second
+	latinizationTimeout = 20 * time.Second
+	dictionaryTimeout   = 2 * time.Minute
+	audioTimeout        = 45 * time.Second
+)
+
+// lookupMessages are the strings of the interface shown while we wait for each kind of lookup.
+var lookupMessages = map[string]int{"audio": 38, "translation": 39, "latinization": 40, "sentence": 41, "alternatives": 57, "review": 39, "dictionary": 66}
 
+/*
+lookupResultMsg struct:
+the result of a lookup of the reader (see startLookup).
+*/
+
+type lookupResultMsg struct {
+	id        int    // The lookup it's the result of (see model.lookupID)
+	kind      string // "audio", "translation", "latinization", "sentence" or "alternatives"
+	key       string // The word (or phrase) the lookup was about
+	text      string // How the word is written in the text (only for "alternatives")
+	result    string // The translation or the latinization
+	errString string
+	// The translations found by mymemory, ranked (only for "alternatives").
+	alternatives []translator.Match
+	// The translation of the sentence from the token sentenceStart to sentenceEnd (only for "sentence").
+	sentence      *translator.SentenceTranslation
+	sentenceStart int
+	sentenceEnd   int
+	// The offline dictionaries of the language (only for "dictionary").
+	library *dictionary.Library
+}
+
+/*
+startLookup method:
+input: the kind of the lookup, the longest time it can take, and the function that does it.
+output: the command that runs the lookup in the background (and makes the spinner turn).
+The lookup we were waiting for is cancelled. When the new one is over, a lookupResultMsg is sent (with an error
+if it took too long); the function gets a context that is cancelled when we don't need the result anymore.
+*/
+
+func (m *model) startLookup(kind string, timeout time.Duration, lookup func(ctx context.Context) lookupResultMsg) tea.Cmd {
+	m.cancelLookup()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	m.lookupKind, m.lookupCancel = kind, cancel
+	id := m.lookupID
+	_, key := m.currentItem()
+	wait := func() tea.Msg {
+		defer cancel()
+		done := make(chan lookupResultMsg, 1)
+		go func() {
+			done <- lookup(ctx)
+		}()
+		var result lookupResultMsg
+		select {
+		case result = <-done:
+		case <-ctx.Done():
+			result = lookupResultMsg{errString: fmt.Sprintf("no answer after %v", timeout)}
 		}
+		result.id, result.kind, result.key = id, kind, key
+		return result
+	}
+	return tea.Batch(wait, m.spinner.Tick)
+}
 
+// cancelLookup cancels the lookup we are waiting for, if there's one (its result will be ignored).
+func (m *model) cancelLookup() {
+	if m.lookupCancel != nil {
+		m.lookupCancel()
+	}
+	m.lookupKind, m.lookupCancel = "", nil
+	m.lookupID++
+}
+
+// finishLookup shows the result of a lookup.
+func (m *model) finishLookup(msg lookupResultMsg) {
+	m.lookupKind, m.lookupCancel = "", nil
+	m.currentError = msg.errString
+	switch msg.kind {
+	case "translation":
+		m.openedFileText.CurrentTranslate = msg.result
+		m.translationKey = msg.key
+		// The translation can come with an error, if the translation cache couldn't be saved.
+		if msg.result != "" {
+			m.saveTranslation(msg.key, msg.result)
+		}
+	case "latinization":
+		m.openedFileText.CurrentLatinization = msg.result
+	case "sentence":
+		m.sentenceTranslation = msg.sentence
+		m.sentenceStart, m.sentenceEnd = msg.sentenceStart, msg.sentenceEnd
+	case "review":
+		// The queue doesn't change while we wait: the word can't be graded before its answer is shown.
+		m.reviewAnswer = msg.result
+		if entry, ok := m.reviewWords.Lookup(m.reviewQueue[0]); ok && entry.Notes != "" {
+			m.reviewAnswer += "\n" + interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][60] + entry.Notes
+		}
+		m.reviewRevealed = true
+	case "dictionary":
+		// If it took too long, the dictionaries are loaded again at the next move of the cursor.
+		if msg.library != nil {
+			m.dictionaryLibrary, m.dictionaryLanguage = msg.library, m.currentLanguage
+		}
+	case "alternatives":
+		if msg.errString == "" && len(msg.alternatives) == 0 {
+			m.currentError = interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][56]
+		}
+		if len(msg.alternatives) > 0 {
+			m.alternatives, m.alternativesText, m.alternativesKey, m.alternativeCursor = msg.alternatives, msg.text, msg.key, 0
+		}
+	}
+}
+
+/*
+chooseAlternative method:
+input: the key pressed while the alternative translations are shown.
+output: the updated model, and the command to run (only to quit).
+j/k (or the arrows) move in the list, enter (or space) saves the alternative we chose as the translation of the word,
+esc closes the list.
+*/
+
+func (m model) chooseAlternative(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "ctrl+c", "q":
+		m.saveProgress()
+		return m, tea.Quit
+	case "up", "k":
+		if m.alternativeCursor > 0 {
+			m.alternativeCursor--
+		}
+	case "down", "j":
+		if m.alternativeCursor < len(m.alternatives)-1 {
+			m.alternativeCursor++
+		}
+	case "enter", " ":
+		translation := m.alternatives[m.alternativeCursor].Translation
+		// If the word has a pinned translation, the choice replaces it (otherwise the pinned one would still win).
+		languageId := languageHandler.LanguageMap2[m.currentLanguage]
+		if _, ok := translator.Pinned(m.currentLanguage, m.alternativesText, languageId, m.bootLanguage); ok {
+			if err := translator.Pin(m.currentLanguage, m.alternativesText, languageId, m.bootLanguage, translation); err != nil {
+				m.currentError = err.Error()
+			}
+		}
+		m.saveTranslation(m.alternativesKey, translation)
+		// The same for the meaning we wrote ourselves (see the e key).
+		if entry, ok := m.openedFileText.Words.Lookup(m.alternativesKey); ok && entry.Meaning != "" {
+			err := m.openedFileText.Words.Update(m.alternativesKey, func(entry *vocabulary.Entry) {
+				entry.Meaning = translation
+			})
+			if err != nil {
+				m.currentError = err.Error()
+			}
+		}
+		m.openedFileText.CurrentTranslate, m.translationKey = translation, m.alternativesKey
+		m.alternatives = nil
+	case "esc", "A":
+		m.alternatives = nil
 	}
 	return m, nil
 }
 
+/*
+startEditing method:
+output: the command that makes the cursor of the editor blink.
+It opens the editor of the meaning of the current word (and of its note), filled with the meaning we already
+wrote or, if there's none, with the translation we have.
+*/
+
+func (m *model) startEditing() tea.Cmd {
+	interfaceText := interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]]
+	itemText, itemKey := m.currentItem()
+	entry, _ := m.openedFileText.Words.Lookup(itemKey)
+	meaning := entry.Meaning
+	if meaning == "" && m.translationKey == itemKey {
+		meaning = m.openedFileText.CurrentTranslate
+	}
+	if meaning == "" {
+		meaning = entry.Translation
+	}
+	width := min(max(m.width-len(interfaceText[59])-4, 20), 80)
+	m.meaningInput = textinput.New()
+	m.meaningInput.Prompt = interfaceText[59]
+	m.meaningInput.Placeholder = interfaceText[64]
+	m.meaningInput.Width = width
+	m.meaningInput.SetValue(meaning)
+	m.noteInput = textinput.New()
+	m.noteInput.Prompt = interfaceText[60]
+	m.noteInput.Placeholder = interfaceText[65]
+	m.noteInput.Width = width
+	m.noteInput.SetValue(entry.Notes)
+	m.editing, m.editText, m.editKey = true, itemText, itemKey
+	return m.meaningInput.Focus()
+}
+
+/*
+updateEditor method:
+input: the message received while the editor of the meaning is open.
+output: the updated model, and the command to run.
+tab (or the arrows) moves between the meaning and the note, enter saves them, esc closes the editor without saving.
+*/
+
+func (m model) updateEditor(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c":
+			m.saveProgress()
+			return m, tea.Quit
+		case "esc":
+			m.editing = false
+			return m, nil
+		case "tab", "shift+tab", "up", "down":
+			if m.meaningInput.Focused() {
+				m.meaningInput.Blur()
+				return m, m.noteInput.Focus()
+			}
+			m.noteInput.Blur()
+			return m, m.meaningInput.Focus()
+		case "enter":
+			m.saveMeaning()
+			return m, nil
+		}
+	}
+	var meaningCmd, noteCmd tea.Cmd
+	m.meaningInput, meaningCmd = m.meaningInput.Update(msg)
+	m.noteInput, noteCmd = m.noteInput.Update(msg)
+	return m, tea.Batch(meaningCmd, noteCmd)
+}
+
+// saveMeaning stores the meaning and the note we wrote in the editor; an empty meaning brings back the translations.
+func (m *model) saveMeaning() {
+	meaning, note := strings.TrimSpace(m.meaningInput.Value()), strings.TrimSpace(m.noteInput.Value())
+	err := m.openedFileText.Words.Update(m.editKey, func(entry *vocabulary.Entry) {
+		entry.Meaning, entry.Notes = meaning, note
+	})
+	if err != nil {
+		m.currentError = err.Error()
+	}
+	if meaning != "" {
+		m.openedFileText.CurrentTranslate, m.translationKey = meaning, m.editKey
+	}
+	m.editing = false
+}
+
+// editorPane shows the editor of the meaning of a word (key e).
+func (m model) editorPane() string {
+	interfaceText := interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]]
+	s := "\n\n" + interfaceText[61] + selectionStyle.Render(m.editText)
+	s += "\n" + m.meaningInput.View()
+	s += "\n" + m.noteInput.View()
+	return s + "\n" + interfaceText[62]
+}
+
+// alternativesPane shows the alternative translations of mymemory (key A), with their score.
+func (m model) alternativesPane() string {
+	interfaceText := interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]]
+	s := "\n\n" + interfaceText[54] + selectionStyle.Render(m.alternativesText)
+	for k, alternative := range m.alternatives {
+		line := fmt.Sprintf("%d. %s", k+1, alternative.Translation)
+		if k == m.alternativeCursor {
+			line = selectedItemStyle.Render(line)
+		}
+		s += "\n" + line + " " + tagStyle.Render(fmt.Sprintf("%.0f%%", alternative.Match*100))
+		// The segment the translation comes from, when mymemory found it for a similar text.
+		if alternative.Segment != "" && !strings.EqualFold(alternative.Segment, m.alternativesText) {
+			s += tagStyle.Render(" (" + alternative.Segment + ")")
+		}
+	}
+	return s + "\n" + interfaceText[55]
+}
+
+// playAudio downloads the pronunciation of a text, plays it and deletes it; it returns the possible errors.
+func playAudio(ctx context.Context, text string, languageId string) string {
+	errString := audioPlayer.GetAudioContext(ctx, text, languageId)
+	mp3FilePath := fmt.Sprintf("audio/%s.mp3", text)
+	if errString == "" {
+		errString = audioPlayer.PlayMP3Context(ctx, mp3FilePath)
+	}
+	if deleteError := audioPlayer.DeleteMP3(mp3FilePath); deleteError != "" && errString == "" {
+		errString = deleteError
+	}
+	return errString
+}
+
+// exportProgressMsg tells how many words of the dictionary file are done, and exportFinishedMsg that the export is over.
+type exportProgressMsg struct {
+	done  int
+	total int
+}
+
+type exportFinishedMsg struct {
+	result fileReader.ExportResult
+	err    error
+}
+
+/*
+startExport method:
+input: the vocabulary to make the dictionary file with, and true for the file with the latinizations (key z)
+or false for the plain one (key f).
+output: the command that waits for the progress of the export.
+The export runs in the background (see fileReader/export.go), while a progress bar shows how it's going (viewIndex 5).
+*/
+
+func (m *model) startExport(words *vocabulary.Store, latinizations bool) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan tea.Msg, 1)
+	m.exportReturn, m.exportUpdates, m.exportCancel = m.viewIndex, updates, cancel
+	m.exportStopping, m.exportDone, m.exportTotal, m.exportResult, m.exportErr = false, 0, 0, nil, nil
+	m.viewIndex = 5
+	m.currentError = ""
+	language, bootLanguage, hanziData := m.currentLanguage, m.bootLanguage, m.hanziData
+	report := func(done int, total int) {
+		// When the interface is behind, a progress update is skipped: the next one replaces it.
+		select {
+		case updates <- exportProgressMsg{done: done, total: total}:
+		default:
+		}
+	}
+	go func() {
+		defer cancel()
+		var result fileReader.ExportResult
+		var err error
+		if latinizations {
+			result, err = fileReader.MakeAltDictionary(ctx, words, language, bootLanguage, hanziData, report)
+		} else {
+			result, err = fileReader.MakeDictionary(ctx, words, language, bootLanguage, report)
+		}
+		updates <- exportFinishedMsg{result: result, err: err}
+	}()
+	return waitExport(updates)
+}
+
+// waitExport waits for the next update of the export of a dictionary file.
+func waitExport(updates <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
+}
+
+// exportView shows the progress of the export of a dictionary file and, when it's over, the words that couldn't be translated.
+func (m model) exportView() string {
+	interfaceText := interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]]
+	s := interfaceText[44] + m.currentLanguage + "\n\n"
+	percent := 0.0
+	if m.exportTotal > 0 {
+		percent = float64(m.exportDone) / float64(m.exportTotal)
+	}
+	bar := m.exportBar
+	bar.Width = min(max(m.width-4, 20), 80)
+	s += bar.ViewAs(percent) + "\n"
+	s += fmt.Sprintf("%s%d/%d\n\n", interfaceText[45], m.exportDone, m.exportTotal)
+	switch {
+	case m.exportResult == nil && m.exportStopping:
+		s += interfaceText[50] + "\n"
+	case m.exportResult == nil:
+		s += interfaceText[52] + "\n"
+	case errors.Is(m.exportErr, context.Canceled):
+		s += interfaceText[51] + "\n"
+	default:
+		// The file can be written even with an error (when only the translation cache couldn't be saved): show both.
+		if m.exportResult.Filename != "" {
+			s += interfaceText[46] + m.exportResult.Filename + "\n"
+			s += fmt.Sprintf("%s%d\n", interfaceText[47], m.exportResult.Words)
+		}
+		if m.exportErr != nil {
+			s += interfaceText[11] + m.exportErr.Error() + "\n"
+		}
+		if failures := m.exportResult.Failures; len(failures) > 0 {
+			s += "\n" + interfaceText[48] + "\n"
+			// Show the failures that fit in the terminal.
+			room := max(m.height-16, 3)
+			for k, failure := range failures {
+				if k == room {
+					s += "...\n"
+					break
+				}
+				reason, _, _ := strings.Cut(failure.Error, "\n")
+				s += notKnownItemStyle.Render(failure.Word) + " " + tagStyle.Render(reason) + "\n"
+			}
+			if m.exportResult.Report != "" {
+				s += interfaceText[49] + m.exportResult.Report + "\n"
+			}
+		}
+		s += "\n" + interfaceText[53] + "\n"
+	}
+	return s + interfaceText[1]
+}
+
+// serverStatusMsg is sent when the tokenization server LinGo is starting is ready, or when starting it failed.
+type serverStatusMsg struct{}
+
+// serverWaitMsg is sent when the tokenization server is ready to tokenize the text we want to open (err is nil),
+// or when it couldn't be started.
+type serverWaitMsg struct {
+	filename string
+	err      error
+}
+
+// waitServer returns the command that waits (in the background) until the tokenization server LinGo started is ready.
+func (m model) waitServer(filename string) tea.Cmd {
+	server := m.server
+	return func() tea.Msg {
+		return serverWaitMsg{filename: filename, err: server.WaitReady(serverManager.ReadyTimeout)}
+	}
+}
+
+/*
+openText method:
+input: the path of a text, the text (see fileReader.InitText) and the error we got while reading it.
+It opens the text in the reader, where we left it.
+*/
+
+func (m *model) openText(filename string, text fileReader.Text, err error) {
+	m.viewIndex = 1
+	m.selecting = false
+	m.sentenceTranslation = nil
+	m.alternatives = nil
+	m.editing = false
+	m.openedFile = filename
+	// If the server of the language is down, the reader tells the user how to start it.
+	if err != nil && !errors.As(err, &m.serverDown) {
+		m.currentError = err.Error()
+	}
+	// Continue reading from where we left (if the vocabulary backend keeps track of it).
+	if position := text.Words.Progress(m.openedFile); position < text.TokenLength {
+		text.TokenCursorPosition = position
+	}
+	text.Paginate(m.textWidth(), m.height)
+	m.openedFileText = text
+}
+
+// checkTokenizer checks if the tokenizer of the current language is ready to be used (e.g if its server is running);
+// if the server isn't running and LinGo knows how to start it, it starts it and returns the command that waits for it.
+func (m *model) checkTokenizer() tea.Cmd {
+	m.serverDown = nil
+	m.waitingText = ""
+	m.currentError = ""
+	m.server = serverManager.Get(m.currentLanguage)
+	tokenizer, err := fileReader.TokenizerFor(m.currentLanguage)
+	if err != nil {
+		m.currentError = err.Error()
+		return nil
+	}
+	checker, ok := tokenizer.(fileReader.HealthChecker)
+	if !ok {
+		return nil
+	}
+	err = checker.Check()
+	if err == nil {
+		return nil
+	}
+	if managed, ok := tokenizer.(fileReader.ManagedServer); ok && len(managed.Command()) > 0 {
+		server := serverManager.Start(m.currentLanguage, managed.Command(), checker.Check)
+		m.server = server
+		return func() tea.Msg {
+			server.WaitReady(serverManager.ReadyTimeout)
+			return serverStatusMsg{}
+		}
+	}
+	errors.As(err, &m.serverDown)
+	return nil
+}
+
+// readServerLog reads the last lines of the log of the tokenization server of the current language.
+func (m *model) readServerLog() {
+	lines, err := serverManager.ReadLog(m.currentLanguage)
+	m.serverLog = lines
+	m.currentError = ""
+	if err != nil {
+		m.currentError = err.Error()
+	}
+}
+
+// serverStatusMessage tells the user what the tokenization server LinGo started is doing.
+func (m model) serverStatusMessage() string {
+	interfaceText := interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]]
+	s := interfaceText[22]
+	state, err := m.server.Status()
+	switch state {
+	case serverManager.Starting:
+		s += interfaceText[23]
+	case serverManager.Ready:
+		s += interfaceText[24]
+	case serverManager.Failed:
+		s += interfaceText[25]
+		if err != nil {
+			s += err.Error()
+		}
+	default:
+		s += interfaceText[28]
+	}
+	return s + "\n" + interfaceText[26]
+}
+
+// serverDownMessage tells the user that the tokenization server of the current language is down, and how to start it.
+func (m model) serverDownMessage() string {
+	interfaceText := interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]]
+	s := interfaceText[20] + m.serverDown.Address + "\n"
+	s += interfaceText[21] + fmt.Sprintf("python3 scriptioContinuaTokenization/server_%s.py %s", m.currentLanguage, m.serverDown.Address) + "\n"
+	s += m.serverDown.Err.Error() + "\n"
+	return s
+}
+
+/*
+sentencePane method:
+it shows the sentence we translated with s/S and its translation; when the cursor is on a word of
+the sentence, the word is highlighted, and so is its translation if the API told us where it is.
+*/
+
+func (m model) sentencePane() string {
+	interfaceText := interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]]
+	tokens := m.openedFileText.TokenList[m.sentenceStart:m.sentenceEnd]
+	source, offsets := fileReader.JoinTokensWithOffsets(tokens)
+	target := m.sentenceTranslation.Translation
+	if cursor := m.openedFileText.TokenCursorPosition - m.sentenceStart; cursor >= 0 && cursor < len(tokens) && offsets[cursor] != -1 {
+		start, end := offsets[cursor], offsets[cursor]+len(tokens[cursor].Text)
+		if targetStart, targetEnd := m.sentenceTranslation.TargetOf(start); targetStart != -1 {
+			target = target[:targetStart] + alignedStyle.Render(target[targetStart:targetEnd]) + target[targetEnd:]
+		}
+		source = source[:start] + alignedStyle.Render(source[start:end]) + source[end:]
+	}
+	return "\n" + interfaceText[31] + source + "\n" + interfaceText[32] + target
+}
+
+// dictionaryPanelWidth is the width of the panel of the offline dictionaries (a third of the terminal, within some limits).
+func (m model) dictionaryPanelWidth() int {
+	return min(max(terminalSize.LineWidth(m.width)/3, 24), 50)
+}
+
+// dictionaryHeight is the number of lines of the panel of the offline dictionaries (as many as the lines of a page).
+func (m model) dictionaryHeight() int {
+	return terminalSize.LinesPerPage(m.height)
+}
+
+// textWidth is the width available to the text in the reader (the terminal, minus the panel of the dictionaries if it's open).
+func (m model) textWidth() int {
+	if !m.dictionaryOpen {
+		return m.width
+	}
+	return terminalSize.LineWidth(m.width) - m.dictionaryPanelWidth()
+}
+
+/*
+lookupDictionary method:
+output: the command that loads the offline dictionaries of the current language, if they aren't loaded yet.
+It looks the word under the cursor (or the selected phrase) up in the offline dictionaries of the current language,
+if it isn't the one the panel is already showing. Reading the dictionaries can take some seconds (JMdict, CC-CEDICT...),
+so the first time they are loaded in the background, like the lookups of the reader (see startLookup): the panel is
+filled when they are ready. It waits for the other lookups of the reader, so that it doesn't cancel them.
+*/
+
+func (m *model) lookupDictionary() tea.Cmd {
+	itemText, itemKey := m.currentItem()
+	if m.dictionaryLanguage != m.currentLanguage {
+		m.dictionaryKey, m.dictionaryEntries = "", nil
+		if m.lookupKind != "" {
+			return nil
+		}
+		language := m.currentLanguage
+		return m.startLookup("dictionary", dictionaryTimeout, func(ctx context.Context) lookupResultMsg {
+			library, err := dictionary.ForLanguage(language)
+			result := lookupResultMsg{library: library}
+			// The dictionaries we couldn't read are reported, the others are in the library anyway.
+			if err != nil {
+				result.errString = err.Error()
+			}
+			return result
+		})
+	}
+	if itemKey == m.dictionaryKey {
+		return nil
+	}
+	m.dictionaryKey = itemKey
+	m.dictionaryScroll = 0
+	m.dictionaryEntries = nil
+	if itemKey == "" {
+		return nil
+	}
+	entries, err := m.dictionaryLibrary.Lookup(itemText)
+	if err != nil {
+		m.currentError = err.Error()
+	}
+	m.dictionaryEntries = entries
+	return nil
+}
+
+/*
+dictionaryLines method:
+it returns the lines of the panel of the offline dictionaries: every entry of the word (the headword, its
+reading and the dictionary it comes from, its tags, then the whole definition), wrapped to fit in the panel.
+*/
+
+func (m model) dictionaryLines() []string {
+	interfaceText := interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]]
+	var content []string
+	// The dictionaries are still being loaded (see lookupDictionary).
+	if m.dictionaryLanguage != m.currentLanguage {
+		content = append(content, interfaceText[66])
+	} else if len(m.dictionaryLibrary.Dictionaries) == 0 {
+		content = append(content, interfaceText[35]+dictionary.Dir(m.currentLanguage))
+	} else if len(m.dictionaryEntries) == 0 {
+		content = append(content, interfaceText[36])
+	}
+	for k, entry := range m.dictionaryEntries {
+		if k != 0 {
+			content = append(content, "")
+		}
+		header := headwordStyle.Render(entry.Headword)
+		if entry.Reading != "" {
+			header += " [" + entry.Reading + "]"
+		}
+		content = append(content, header+" ("+entry.Dictionary+")")
+		if len(entry.Tags) > 0 {
+			content = append(content, tagStyle.Render(strings.Join(entry.Tags, ", ")))
+		}
+		content = append(content, entry.Definition)
+	}
+	wrapped := lipgloss.NewStyle().Width(m.dictionaryPanelWidth() - 2).Render(strings.Join(content, "\n"))
+	return strings.Split(wrapped, "\n")
+}
+
+// dictionaryPanel renders the visible part of the panel of the offline dictionaries.
+func (m model) dictionaryPanel() string {
+	interfaceText := interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]]
+	lines := m.dictionaryLines()
+	height := m.dictionaryHeight()
+	scroll := min(m.dictionaryScroll, max(len(lines)-height, 0))
+	visible := lines[scroll:min(scroll+height, len(lines))]
+	title := interfaceText[34]
+	if len(lines) > height {
+		title += fmt.Sprintf(" %v/%v", scroll+len(visible), len(lines))
+	}
+	return dictionaryPanelStyle.Render(title + "\n" + strings.Join(visible, "\n"))
+}
+
+// renderLevel colors a word (or a phrase) according to its level of knowledge.
+func renderLevel(level int, element string) string {
+	switch level {
+	case 1:
+		return notKnownItemStyle.Render(element)
+	case 2:
+		return semiKnownItemStyle.Render(element)
+	case 3:
+		return knownItemStyle.Render(element)
+	default:
+		return element
+	}
+}
+
 func (m model) View() string {
 	var s string
 	if m.viewIndex == 0 {
 		// The header
 		s = interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][2] + m.currentLanguage + "\n"
 		s += "\n"
+		if m.serverDown != nil {
+			s += notKnownItemStyle.Render(m.serverDownMessage()) + "\n"
+		}
+		if m.server != nil {
+			s += m.serverStatusMessage() + "\n"
+		}
+		if m.waitingText != "" {
+			s += m.spinner.View() + " " + interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][67] + "\n"
+		}
+		if m.currentError != "" {
+			s += interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][11] + m.currentError + "\n\n"
+		}
 
 		// Iterate over our choices
 
@@ -449,55 +1583,144 @@ func (m model) View() string {
 		// The footer
 		s += interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][4]
 		s += interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][5]
+		s += interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][14]
 		s += "\n" + interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][1] + "\n"
 	} else if m.viewIndex == 1 {
-		wordsPerLine := terminalSize.GetWordsPerLine()
-		linesPerPage := terminalSize.GetLinesPerPage()
-		width, height := terminalSize.GetTerminalSize()
+		width, height := m.width, m.height
 
 		s = interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][6] + m.openedFile + interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][7]
 		s += fmt.Sprintf("%v", m.openedFileText.TokenCursorPosition)
 		s += fmt.Sprintf("\n%s %v %v\n", interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][8], width, height)
 		s += "\n"
-		for k, element := range m.openedFileText.PageList[m.openedFileText.CurrentPage] {
-			var padding1 string = ""
-			var padding2 string = " "
-			if k%wordsPerLine == 0 && k != 0 {
-				padding2 = "\n"
+		if m.serverDown != nil {
+			s += notKnownItemStyle.Render(m.serverDownMessage())
+		}
+		tokens := m.openedFileText.TokenList
+		page := m.openedFileText.PageList[m.openedFileText.CurrentPage]
+		// The words that are part of a phrase of the vocabulary take the color of the phrase.
+		phraseLevels := map[int]int{}
+		for _, phrase := range m.openedFileText.PagePhrases(m.openedFileText.CurrentPage) {
+			for k := phrase.Start; k < phrase.End; k++ {
+				phraseLevels[k] = phrase.Level
+			}
+		}
+		selectionStart, selectionEnd := 0, 0
+		if m.selecting {
+			selectionStart, selectionEnd = fileReader.Selection(m.selectionAnchor, m.openedFileText.TokenCursorPosition)
+		}
+		var pageView string
+		for l, line := range page.Lines {
+			if l != 0 {
+				pageView += "\n"
 			}
-			s += padding1
-			actualKey := k + (m.openedFileText.CurrentPage * wordsPerLine * linesPerPage)
-			if actualKey == m.openedFileText.TokenCursorPosition {
-				s += selectedItemStyle.Render(element)
-			} else if value, ok := m.openedFileText.WordLevels[element]; ok {
-				switch value {
-				case 0:
-					s += element
-				case 1:
-					s += notKnownItemStyle.Render(element)
-				case 2:
-					s += semiKnownItemStyle.Render(element)
-				case 3:
-					s += knownItemStyle.Render(element)
-				default:
-					s += element
+			for k := line.Start; k < line.End; k++ {
+				token := tokens[k]
+				// Layout tokens only end the line, there's nothing to display.
+				if token.IsLayout() {
+					continue
+				}
+				// Put a space where the text had whitespace.
+				if token.SpaceBefore && k != line.Start {
+					pageView += " "
+				}
+				element := token.Text
+				if k == m.openedFileText.TokenCursorPosition {
+					pageView += selectedItemStyle.Render(element)
+				} else if k >= selectionStart && k < selectionEnd {
+					pageView += selectionStyle.Render(element)
+				} else if level, ok := phraseLevels[k]; ok {
+					pageView += renderLevel(level, element)
+				} else if value, ok := m.openedFileText.Words.Lookup(token.Key); ok && token.IsWord() {
+					pageView += renderLevel(value.Level, element)
+				} else {
+					pageView += element
 				}
-			} else {
-				s += element
 			}
-			s += padding2
 		}
+		// The panel of the dictionaries is shown on the right of the text.
+		if m.dictionaryOpen {
+			pageView = lipgloss.JoinHorizontal(lipgloss.Top, lipgloss.NewStyle().Width(m.textWidth()).Render(pageView), m.dictionaryPanel())
+		}
+		s += pageView
 		s += "\n"
 		s += fmt.Sprintf("%v", m.openedFileText.CurrentPage)
 		s += fmt.Sprintf("\n%s %v", interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][9], m.openedFileText.Pages)
 		s += "\n"
 		s += fmt.Sprintf("%s %s", interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][10], m.openedFileText.CurrentTranslate)
+		if m.pinned() {
+			s += tagStyle.Render(interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][42])
+		}
 		s += "\n"
 		s += fmt.Sprintf("%s %s", interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][13], m.openedFileText.CurrentLatinization)
+		if m.sentenceTranslation != nil {
+			s += m.sentencePane()
+		}
+		if m.alternatives != nil {
+			s += m.alternativesPane()
+		}
+		// The meaning we wrote for the current word (and its note), or the editor if we're writing them.
+		if m.editing {
+			s += m.editorPane()
+		} else if _, itemKey := m.currentItem(); itemKey != "" {
+			entry, _ := m.openedFileText.Words.Lookup(itemKey)
+			if entry.Meaning != "" {
+				s += "\n" + interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][59] + headwordStyle.Render(entry.Meaning)
+			}
+			if entry.Notes != "" {
+				s += "\n" + interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][60] + tagStyle.Render(entry.Notes)
+			}
+		}
+		if m.lookupKind != "" {
+			s += "\n" + m.spinner.View() + " " + interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][lookupMessages[m.lookupKind]]
+		}
+		if m.selecting {
+			phrase, _ := m.currentItem()
+			s += "\n" + interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][29] + selectionStyle.Render(phrase)
+		}
 		s += "\n" + interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][11] + m.currentError
-		s += "\n" + interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][12] + "\n" + interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][1]
+		s += "\n" + interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][12]
+		s += "\n" + interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][30]
+		s += "\n" + interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][33]
+		s += "\n" + interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][37]
+		s += "\n" + interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][43]
+		s += "\n" + interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][58]
+		s += "\n" + interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][63] + "\n" + interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]][1]
 	} else if m.viewIndex == 2 {
 		return baseStyle.Render(m.languageTable.View()) + "\n"
+	} else if m.viewIndex == 3 {
+		interfaceText := interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]]
+		s = interfaceText[2] + m.currentLanguage + "\n\n"
+		s += fmt.Sprintf("%s%v\n\n", interfaceText[15], len(m.reviewQueue))
+		if len(m.reviewQueue) == 0 {
+			s += interfaceText[18] + "\n"
+		} else {
+			s += selectedItemStyle.Render(m.reviewQueue[0]) + "\n\n"
+			if m.reviewRevealed {
+				s += fmt.Sprintf("%s %s\n\n", interfaceText[10], m.reviewAnswer)
+				s += interfaceText[17] + "\n"
+			} else if m.lookupKind != "" {
+				s += m.spinner.View() + " " + interfaceText[lookupMessages[m.lookupKind]] + "\n"
+			} else {
+				s += interfaceText[16] + "\n"
+			}
+		}
+		s += "\n" + interfaceText[11] + m.currentError
+		s += "\n" + interfaceText[19] + "\n" + interfaceText[1]
+	} else if m.viewIndex == 4 {
+		interfaceText := interfaceLanguage.InterfaceLanguage[interfaceLanguage.LanguagesCodeMap[m.bootLanguage]]
+		s = interfaceText[27] + "\n\n"
+		// Show the lines at the end of the log that fit in the terminal.
+		lines := m.serverLog
+		if room := m.height - 6; room > 0 && len(lines) > room {
+			lines = lines[len(lines)-room:]
+		}
+		for _, line := range lines {
+			s += line + "\n"
+		}
+		s += "\n" + interfaceText[11] + m.currentError
+		s += "\n" + interfaceText[1]
+	} else if m.viewIndex == 5 {
+		s = m.exportView()
 	}
 
 	// Send the UI for rendering
@@ -519,7 +1742,18 @@ func main() {
 	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
 	// If there's an error in the running of the application, let the user know
 	// by printing it out to the console.
-	if _, err := p.Run(); err != nil {
+	_, err = p.Run()
+	// Stop the tokenization servers LinGo started.
+	serverManager.StopAll()
+	// Close the vocabularies we opened (this makes sure that everything is written on disk).
+	if err2 := vocabulary.CloseAll(); err2 != nil {
+		fmt.Printf("Error while saving the vocabulary: %v\n", err2)
+	}
+	// The same for the translations we got (see translator/cache.go).
+	if err2 := translator.FlushCaches(); err2 != nil {
+		fmt.Printf("Error while saving the translations: %v\n", err2)
+	}
+	if err != nil {
 		fmt.Printf("Alas, there's been an error: %v", err)
 		os.Exit(1)
 	}
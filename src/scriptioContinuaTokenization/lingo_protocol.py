@@ -0,0 +1,104 @@
+# The protocol LinGo uses to talk with the tokenization servers.
+#
+# Every message is a json object on a single line (newline-delimited json).
+# LinGo sends a request with an id and the lines of (a chunk of) the text:
+#     {"id": 1, "lines": ["first line", "", "second paragraph"]}
+# and the server answers with the same id and the tokens of every line:
+#     {"id": 1, "tokens": [["first", "line"], [], ["second", "paragraph"]]}
+# or, if something went wrong:
+#     {"id": 1, "error": "what went wrong"}
+# The lines are tokenized separately, so that LinGo can keep the line breaks and
+# the paragraphs of the text. A connection can carry many requests, one after the other.
+# A request without lines is a health check: the server answers {"id": 1, "tokens": [], "version": "..."},
+# where the version tells LinGo when the tokens it cached are out of date (e.g after updating a model).
+#
+# Every server listens on the address passed as its first argument, either host:port or
+# unix:/path/of/the/socket, e.g:
+#     python3 server_japanese.py 127.0.0.1:9000
+# and otherwise on the default address of its language (the same one LinGo uses).
+
+import importlib.metadata
+import json
+import os
+import socket
+import sys
+import threading
+
+
+def package_version(*names):
+    """The installed version of some python packages, e.g package_version("spacy", "ja-core-news-sm")."""
+    versions = []
+    for name in names:
+        try:
+            versions.append(f"{name} {importlib.metadata.version(name)}")
+        except importlib.metadata.PackageNotFoundError:
+            versions.append(f"{name} unknown")
+    return ", ".join(versions)
+
+
+def handle_request(message, tokenize, version):
+    try:
+        request = json.loads(message)
+    except ValueError as error:
+        return {"id": None, "error": f"invalid request: {error}"}
+    request_id = request.get("id")
+    if not request.get("lines"):
+        return {"id": request_id, "tokens": [], "version": version}
+    try:
+        tokens = []
+        for line in request.get("lines", []):
+            if line.strip() == "":
+                tokens.append([])
+            else:
+                tokens.append([str(token) for token in tokenize(line)])
+        return {"id": request_id, "tokens": tokens}
+    except Exception as error:
+        return {"id": request_id, "error": str(error)}
+
+
+def handle_connection(client_socket, tokenize, version):
+    with client_socket:
+        reader = client_socket.makefile('r', encoding='utf-8', newline='\n')
+        for message in reader:
+            if message.strip() == "":
+                continue
+            response = handle_request(message, tokenize, version)
+            client_socket.sendall((json.dumps(response) + "\n").encode('utf-8'))
+
+
+def listen(address):
+    if address.startswith("unix:"):
+        path = address[len("unix:"):]
+        # Remove the socket left behind by a previous run.
+        if os.path.exists(path):
+            os.remove(path)
+        server_socket = socket.socket(socket.AF_UNIX, socket.SOCK_STREAM)
+        server_socket.bind(path)
+    else:
+        host, port = address.rsplit(":", 1)
+        server_socket = socket.socket(socket.AF_INET, socket.SOCK_STREAM)
+        server_socket.setsockopt(socket.SOL_SOCKET, socket.SO_REUSEADDR, 1)
+        server_socket.bind((host, int(port)))
+    server_socket.listen(5)
+    return server_socket
+
+
+def serve(tokenize, default_address, version=""):
+    """Listen for LinGo's requests, tokenizing every line of text with the tokenize function.
+
+    The version should change whenever the tokens might change (e.g when a model is updated)."""
+    address = sys.argv[1] if len(sys.argv) > 1 else default_address
+    server_socket = listen(address)
+
+    print(f"Server listening on {address}", flush=True)
+
+    # LinGo interrupts the server when it quits (if it started it).
+    try:
+        while True:
+            client_socket, addr = server_socket.accept()
+            print(f"Connection from {addr or 'unix socket'}", flush=True)
+            threading.Thread(target=handle_connection, args=(client_socket, tokenize, version), daemon=True).start()
+    except KeyboardInterrupt:
+        print("Server stopped", flush=True)
+    finally:
+        server_socket.close()
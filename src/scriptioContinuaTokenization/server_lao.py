@@ -1,37 +1,11 @@
-import json
-import socket
 from laonlp import word_tokenize
 
-def start_server():
-    host = '127.0.0.1'
-    port = 8080
+from lingo_protocol import package_version, serve
 
-    server_socket = socket.socket(socket.AF_INET, socket.SOCK_STREAM)
-    server_socket.bind((host, port))
-    server_socket.listen(5)
 
-    print(f"Server listening on {host}:{port}")
+def tokenize(line):
+    return word_tokenize(line)
 
-    while True:
-        client_socket, addr = server_socket.accept()
-        print(f"Connection from {addr}")
-
-        data = client_socket.recv(262144).decode('utf-8')
-        
-
-        print(word_tokenize(data))
-
-
-        # Process the data or perform any desired actions
-
-        responseObject = {
-            "tokens":word_tokenize(data)
-        }
-        json_data = json.dumps(responseObject)
-        print(json_data.encode("utf-8"))
-        client_socket.send(json_data.encode("utf-8"))
-
-        client_socket.close()
 
 if __name__ == "__main__":
-    start_server()
\ No newline at end of file
+    serve(tokenize, '127.0.0.1:8083', package_version("laonlp"))
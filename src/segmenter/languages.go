@@ -0,0 +1,116 @@
+package segmenter
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"unicode"
+)
+
+// source is a file the words of a language are loaded from, together with the method that parses it.
+type source struct {
+	path string
+	load func(l *Lexicon, path string) error
+}
+
+/*
+languages map:
+for every language we can segment natively, the script it is written in and the files its
+lexicon is made of. The files that don't exist are skipped, so the users can add a word list
+(or a whole dictionary) in the directory of the language to improve the segmentation.
+*/
+
+var languages = map[string]struct {
+	script   *unicode.RangeTable
+	strategy Strategy
+	sources  []source
+}{
+	"chinese": {unicode.Han, MaximumMatching, []source{
+		// A list of common words that ships with LinGo.
+		{"segmenter/lexicons/chinese.txt", (*Lexicon).LoadWordList},
+		// The characters we have the pinyin of.
+		{"translator/hanzi.json", (*Lexicon).LoadHanzi},
+		// A word list and a CC-CEDICT dictionary provided by the user.
+		{"languages/chinese/lexicon.txt", (*Lexicon).LoadWordList},
+		{"languages/chinese/cedict_ts.u8", (*Lexicon).LoadCEDICT},
+	}},
+	"thai": {unicode.Thai, MaximalMatching, []source{
+		{"segmenter/lexicons/thai.txt", (*Lexicon).LoadWordList},
+		{"languages/thai/lexicon.txt", (*Lexicon).LoadWordList},
+	}},
+	"lao": {unicode.Lao, MaximalMatching, []source{
+		{"segmenter/lexicons/lao.txt", (*Lexicon).LoadWordList},
+		{"languages/lao/lexicon.txt", (*Lexicon).LoadWordList},
+	}},
+	"khmer": {unicode.Khmer, MaximalMatching, []source{
+		{"segmenter/lexicons/khmer.txt", (*Lexicon).LoadWordList},
+		{"languages/khmer/lexicon.txt", (*Lexicon).LoadWordList},
+	}},
+	"burmese": {unicode.Myanmar, MaximalMatching, []source{
+		// The dictionary of the python word breaker of the scriptioContinuaTokenization folder.
+		{"scriptioContinuaTokenization/word_breaker/dictionary/dict-words.txt", (*Lexicon).LoadWordList},
+		{"languages/burmese/lexicon.txt", (*Lexicon).LoadWordList},
+	}},
+}
+
+var (
+	lexicons   = map[string]*Lexicon{}
+	lexiconsMu sync.Mutex
+)
+
+// Supported checks if we can segment a language natively.
+func Supported(language string) bool {
+	_, ok := languages[language]
+	return ok
+}
+
+/*
+LexiconFor function:
+input: a language.
+output: the lexicon of the language, and a possible error.
+The lexicon is loaded the first time it is requested and then kept in memory.
+*/
+
+func LexiconFor(language string) (*Lexicon, error) {
+	lexiconsMu.Lock()
+	defer lexiconsMu.Unlock()
+	if lexicon, ok := lexicons[language]; ok {
+		return lexicon, nil
+	}
+	info, ok := languages[language]
+	if !ok {
+		return nil, fmt.Errorf("there is no lexicon for %s", language)
+	}
+	lexicon := NewLexicon(info.script)
+	lexicon.Strategy = info.strategy
+	for _, source := range info.sources {
+		err := source.load(lexicon, source.path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source.path, err)
+		}
+	}
+	lexicons[language] = lexicon
+	return lexicon, nil
+}
+
+/*
+LoadLexicon function:
+input: the script of the words, the strategy used to split the texts and the paths of some word lists.
+output: the lexicon made of the words in the lists, and a possible error.
+It is used for the languages that have their own lexicon in setup/config.json; unlike the
+lexicons that ship with LinGo, all the word lists have to exist.
+*/
+
+func LoadLexicon(script *unicode.RangeTable, strategy Strategy, paths []string) (*Lexicon, error) {
+	lexicon := NewLexicon(script)
+	lexicon.Strategy = strategy
+	for _, path := range paths {
+		if err := lexicon.LoadWordList(path); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return lexicon, nil
+}
@@ -0,0 +1,191 @@
+/*
+	=====================================================================
+
+** segmenter package **
+This package splits the text of the languages that don't use spaces
+between words (chinese, thai, lao, khmer, burmese exc.) into words,
+by looking up the words in a lexicon (a list of the words of the language).
+Everything runs in process, so these languages don't need any server.
+
+    =====================================================================
+*/
+
+package segmenter
+
+/*
+Imported packages:
+1) bufio --> used to read the word lists line by line.
+2) encoding/json --> used to read the translator/hanzi.json file.
+3) os --> used to open the files.
+4) strings --> used to parse the lines of the word lists.
+5) unicode, unicode/utf8 --> used to check the script of a text and count its characters.
+6) hash/fnv --> used to compute the checksum of the words of a lexicon.
+*/
+
+import (
+	"bufio"
+	"encoding/json"
+	"hash/fnv"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+Lexicon struct:
+the set of the words of a language, written in a certain script.
+Only the runs of text written in that script are segmented with the lexicon;
+everything else (latin words, numbers, punctuation) is left to the usual tokenizer.
+*/
+
+type Lexicon struct {
+	Strategy  Strategy            // How the text is split into words (see segment.go)
+	script    *unicode.RangeTable // The script the words of the language are written in
+	words     map[string]bool     // The words of the language
+	maxLength int                 // The length (in characters) of the longest word
+	checksum  uint64              // The sum of the hashes of the words (see Checksum)
+}
+
+// NewLexicon creates an empty lexicon for a script (it uses maximum matching, see segment.go).
+func NewLexicon(script *unicode.RangeTable) *Lexicon {
+	return &Lexicon{script: script, words: map[string]bool{}}
+}
+
+// Add adds a word to the lexicon.
+func (l *Lexicon) Add(word string) {
+	word = strings.TrimSpace(word)
+	if word == "" {
+		return
+	}
+	if !l.words[word] {
+		hash := fnv.New64a()
+		hash.Write([]byte(word))
+		l.checksum += hash.Sum64()
+	}
+	l.words[word] = true
+	if length := utf8.RuneCountInString(word); length > l.maxLength {
+		l.maxLength = length
+	}
+}
+
+// Contains checks if a word is in the lexicon.
+func (l *Lexicon) Contains(word string) bool {
+	return l.words[word]
+}
+
+// Size returns the number of words in the lexicon.
+func (l *Lexicon) Size() int {
+	return len(l.words)
+}
+
+// Checksum returns a hash of the words in the lexicon (it doesn't depend on the order they were added in).
+func (l *Lexicon) Checksum() uint64 {
+	return l.checksum
+}
+
+/*
+Handles method:
+input: a piece of text.
+output: true if the text is made of letters of the script of the lexicon (and combining marks),
+i.e if it has to be segmented with the lexicon. The digits and punctuation marks of the script
+are left to the usual tokenizer.
+*/
+
+func (l *Lexicon) Handles(text string) bool {
+	if text == "" {
+		return false
+	}
+	hasLetter := false
+	for _, char := range text {
+		if unicode.Is(l.script, char) && unicode.IsLetter(char) {
+			hasLetter = true
+		} else if !unicode.IsMark(char) {
+			return false
+		}
+	}
+	return hasLetter
+}
+
+/*
+LoadWordList method:
+input: the path of a word list.
+output: a possible error.
+A word list has a word per line; anything after the word on the same line (e.g a
+frequency) is ignored, and so are the empty lines and the lines starting with #.
+*/
+
+func (l *Lexicon) LoadWordList(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Some word lists start with a byte order mark.
+		line := strings.TrimPrefix(scanner.Text(), "\ufeff")
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		l.Add(fields[0])
+	}
+	return scanner.Err()
+}
+
+/*
+LoadCEDICT method:
+input: the path of a CC-CEDICT file (e.g cedict_ts.u8, from https://www.mdbg.net/chinese/dictionary?page=cedict).
+output: a possible error.
+Every line of the file looks like "Traditional Simplified [pin1 yin1] /meaning/meaning/":
+we add both the traditional and the simplified form of the word.
+*/
+
+func (l *Lexicon) LoadCEDICT(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	// The lines with many meanings can be longer than the default buffer.
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		l.Add(fields[0])
+		l.Add(fields[1])
+	}
+	return scanner.Err()
+}
+
+/*
+LoadHanzi method:
+input: the path of the hanzi.json file (the one in the translator package).
+output: a possible error.
+It adds every character of the file as a single-character word.
+*/
+
+func (l *Lexicon) LoadHanzi(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var hanzi map[string][]string
+	if err := json.Unmarshal(content, &hanzi); err != nil {
+		return err
+	}
+	for character := range hanzi {
+		l.Add(character)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package segmenter
+
+import (
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+/*
+Strategy type:
+the algorithm a lexicon uses to split a text into words.
+*/
+
+type Strategy int
+
+const (
+	// MaximumMatching splits the text taking the longest word at every step (it works well for chinese).
+	MaximumMatching Strategy = iota
+	// MaximalMatching looks for the split with the fewest characters left out of the words of the
+	// lexicon and then with the fewest words (it works better for thai, lao, khmer and burmese, where
+	// the longest word is often wrong).
+	MaximalMatching
+)
+
+/*
+Segment method:
+input: a run of text written in the script of the lexicon (without spaces).
+output: the words of the text.
+*/
+
+func (l *Lexicon) Segment(text string) []string {
+	characters := graphemes(text)
+	if l.Strategy == MaximalMatching {
+		return l.maximalMatch(characters)
+	}
+	return l.maximumMatch(characters)
+}
+
+/*
+maximumMatch method:
+We use bidirectional maximum matching: we split the text both from left to right and
+from right to left, always taking the longest word of the lexicon that fits, and we keep
+the split with fewer words (or, if they have the same number of words, the one with fewer
+single characters, since those are usually the characters we couldn't match).
+The characters that aren't part of any word of the lexicon become words of their own.
+*/
+
+func (l *Lexicon) maximumMatch(characters []string) []string {
+	forward := l.forwardMatch(characters)
+	backward := l.backwardMatch(characters)
+	if len(forward) != len(backward) {
+		if len(forward) < len(backward) {
+			return forward
+		}
+		return backward
+	}
+	if singles(forward) < singles(backward) {
+		return forward
+	}
+	return backward
+}
+
+// forwardMatch splits the characters from left to right, taking the longest word at every step.
+func (l *Lexicon) forwardMatch(characters []string) []string {
+	var words []string
+	for start := 0; start < len(characters); {
+		length := min(l.maxLength, len(characters)-start)
+		for ; length > 1; length-- {
+			if l.Contains(strings.Join(characters[start:start+length], "")) {
+				break
+			}
+		}
+		words = append(words, strings.Join(characters[start:start+length], ""))
+		start += length
+	}
+	return words
+}
+
+// backwardMatch splits the characters from right to left, taking the longest word at every step.
+func (l *Lexicon) backwardMatch(characters []string) []string {
+	var reversed []string
+	for end := len(characters); end > 0; {
+		length := min(l.maxLength, end)
+		for ; length > 1; length-- {
+			if l.Contains(strings.Join(characters[end-length:end], "")) {
+				break
+			}
+		}
+		reversed = append(reversed, strings.Join(characters[end-length:end], ""))
+		end -= length
+	}
+	words := make([]string, len(reversed))
+	for k, word := range reversed {
+		words[len(reversed)-1-k] = word
+	}
+	return words
+}
+
+/*
+maximalMatch method:
+it finds, with dynamic programming, the split of the characters that leaves the fewest
+characters out of the words of the lexicon and, among those, the one with the fewest words.
+The characters left out that are next to each other (e.g a name we don't know) are kept
+together in a single word.
+*/
+
+func (l *Lexicon) maximalMatch(characters []string) []string {
+	type split struct {
+		unknown int  // how many characters are not part of a word of the lexicon
+		words   int  // how many words there are
+		start   int  // where the last word starts
+		known   bool // whether the last word is in the lexicon
+	}
+	// best[i] is the best split of the first i characters.
+	best := make([]split, len(characters)+1)
+	for end := 1; end <= len(characters); end++ {
+		// Leaving the last character out is always possible.
+		best[end] = split{unknown: best[end-1].unknown + 1, words: best[end-1].words + 1, start: end - 1}
+		for length := 1; length <= min(l.maxLength, end); length++ {
+			previous := best[end-length]
+			if !l.Contains(strings.Join(characters[end-length:end], "")) {
+				continue
+			}
+			candidate := split{unknown: previous.unknown, words: previous.words + 1, start: end - length, known: true}
+			if candidate.unknown < best[end].unknown || (candidate.unknown == best[end].unknown && candidate.words < best[end].words) {
+				best[end] = candidate
+			}
+		}
+	}
+	// Walk the splits backwards, merging the characters left out that are next to each other.
+	var reversed []string
+	for end := len(characters); end > 0; {
+		start := best[end].start
+		if !best[end].known {
+			for start > 0 && !best[start].known {
+				start = best[start].start
+			}
+		}
+		reversed = append(reversed, strings.Join(characters[start:end], ""))
+		end = start
+	}
+	words := make([]string, len(reversed))
+	for k, word := range reversed {
+		words[len(reversed)-1-k] = word
+	}
+	return words
+}
+
+// graphemes splits a text into user-perceived characters (a character together with its combining marks).
+func graphemes(text string) []string {
+	var characters []string
+	state := -1
+	for len(text) > 0 {
+		var character string
+		character, text, _, state = uniseg.FirstGraphemeClusterInString(text, state)
+		characters = append(characters, character)
+	}
+	return characters
+}
+
+// singles counts the words made of a single character.
+func singles(words []string) int {
+	count := 0
+	for _, word := range words {
+		if uniseg.GraphemeClusterCount(word) == 1 {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,348 @@
+/*
+	=====================================================================
+
+** serverManager package **
+This package is responsible for the tokenization servers LinGo starts by
+itself (e.g scriptioContinuaTokenization/server_japanese.py): it starts the
+server of a language when the language is selected, waits until it's ready
+to answer, starts it again if it crashes and stops it when LinGo quits.
+Everything the servers print is written in a log file
+(languages/<language>/tokenizer.log), that can be read from the app.
+
+    =====================================================================
+*/
+
+package serverManager
+
+/*
+Imported packages:
+1) bufio --> used to read the log file line by line.
+2) errors, fmt --> used to report what went wrong.
+3) os, os/exec --> used to run the servers and to write their log.
+4) strings --> used to write the command in the log.
+5) sync --> used to protect the state of the servers, which is changed by the goroutines that watch them.
+6) time --> used for the timeouts and the delays between the restarts.
+*/
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ReadyTimeout  = 2 * time.Minute        // How long we wait for a server to be ready (loading a model can take a while)
+	PollInterval  = 250 * time.Millisecond // How often we check if a server that is starting is ready
+	StopTimeout   = 3 * time.Second        // How long we wait for a server to quit before killing it
+	RestartDelay  = time.Second            // How long we wait before restarting a server that crashed (it grows with every crash)
+	MaxRestarts   = 5                      // How many times in a row a server can crash before we give up
+	StableUptime  = time.Minute            // A server that stayed up this long didn't crash "in a row"
+	LogTailLength = 200                    // How many lines of the log we keep when we read it
+)
+
+/*
+State type:
+what a server is doing.
+*/
+
+type State int
+
+const (
+	Starting State = iota // The server was started (or restarted) and isn't ready yet
+	Ready                 // The server answers to the health checks
+	Failed                // The server crashed too many times (or couldn't be started): we gave up
+	Stopped               // The server was stopped
+)
+
+func (s State) String() string {
+	switch s {
+	case Starting:
+		return "starting"
+	case Ready:
+		return "ready"
+	case Failed:
+		return "failed"
+	default:
+		return "stopped"
+	}
+}
+
+/*
+Server struct:
+a tokenization server run by LinGo.
+*/
+
+type Server struct {
+	Language string       // The language the server tokenizes
+	Command  []string     // The command that starts the server
+	LogPath  string       // The file where the output of the server is written
+	check    func() error // Checks if the server is ready to answer (see fileReader.CheckServer)
+
+	mu       sync.Mutex
+	state    State
+	err      error // Why the server crashed the last time
+	restarts int   // How many times in a row the server crashed
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+var (
+	servers   = map[string]*Server{}
+	serversMu sync.Mutex
+)
+
+// LogPath returns the path of the log file of the server of a language.
+func LogPath(language string) string {
+	return fmt.Sprintf("languages/%s/tokenizer.log", language)
+}
+
+/*
+Start function:
+input: a language, the command that starts its server and a function that checks if the server is ready.
+output: the server, which is being started in the background (see WaitReady).
+If the server of the language is already running (or starting), nothing is started again.
+*/
+
+func Start(language string, command []string, check func() error) *Server {
+	serversMu.Lock()
+	defer serversMu.Unlock()
+	if server, ok := servers[language]; ok {
+		if state, _ := server.Status(); state == Starting || state == Ready {
+			return server
+		}
+	}
+	server := &Server{
+		Language: language,
+		Command:  command,
+		LogPath:  LogPath(language),
+		check:    check,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	servers[language] = server
+	go server.supervise()
+	return server
+}
+
+// Get returns the server LinGo started for a language, or nil if there's none.
+func Get(language string) *Server {
+	serversMu.Lock()
+	defer serversMu.Unlock()
+	return servers[language]
+}
+
+// StopAll stops all the servers LinGo started (it is called when LinGo quits).
+func StopAll() {
+	serversMu.Lock()
+	defer serversMu.Unlock()
+	for language, server := range servers {
+		server.Stop()
+		delete(servers, language)
+	}
+}
+
+// Status returns what the server is doing, and why it crashed the last time (if it did).
+func (s *Server) Status() (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state, s.err
+}
+
+func (s *Server) setStatus(state State, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+	if err != nil {
+		s.err = err
+	}
+}
+
+/*
+WaitReady method:
+input: how long we can wait.
+output: nil if the server is ready, otherwise an error that says why it isn't.
+*/
+
+func (s *Server) WaitReady(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		state, err := s.Status()
+		switch state {
+		case Ready:
+			return nil
+		case Failed:
+			return fmt.Errorf("the tokenization server of %s couldn't be started: %w", s.Language, err)
+		case Stopped:
+			return fmt.Errorf("the tokenization server of %s was stopped", s.Language)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("the tokenization server of %s isn't ready yet (see %s)", s.Language, s.LogPath)
+		}
+		time.Sleep(PollInterval)
+	}
+}
+
+// Stop stops the server, and waits until it has quit.
+func (s *Server) Stop() {
+	s.mu.Lock()
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	s.mu.Unlock()
+	<-s.done
+}
+
+/*
+supervise method:
+it runs the server until it is stopped, restarting it every time it crashes; if it crashes
+MaxRestarts times in a row, we give up.
+*/
+
+func (s *Server) supervise() {
+	defer close(s.done)
+	for {
+		started := time.Now()
+		err := s.run()
+		if s.stopped() {
+			s.setStatus(Stopped, nil)
+			return
+		}
+		s.mu.Lock()
+		// A server that crashes after working for a while isn't broken, it just has to be restarted.
+		if time.Since(started) > StableUptime {
+			s.restarts = 0
+		}
+		s.restarts++
+		restarts := s.restarts
+		s.mu.Unlock()
+		if restarts > MaxRestarts {
+			s.setStatus(Failed, err)
+			return
+		}
+		s.setStatus(Starting, err)
+		select {
+		case <-s.stop:
+			s.setStatus(Stopped, nil)
+			return
+		case <-time.After(RestartDelay * time.Duration(restarts)):
+		}
+	}
+}
+
+// stopped checks if the server was asked to stop.
+func (s *Server) stopped() bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+/*
+run method:
+output: why the server quit.
+It starts the server, with its output written in the log file, marks it as ready as soon as it
+answers the health checks and waits until it quits (or until it has to be stopped).
+*/
+
+func (s *Server) run() error {
+	if len(s.Command) == 0 {
+		return errors.New("there's no command to start the server")
+	}
+	logFile, err := os.OpenFile(s.LogPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+	fmt.Fprintf(logFile, "=== %s: %s\n", time.Now().Format(time.DateTime), strings.Join(s.Command, " "))
+
+	cmd := exec.Command(s.Command[0], s.Command[1:]...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(logFile, "=== the server couldn't be started: %v\n", err)
+		return err
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	s.setStatus(Starting, nil)
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+	deadline := time.After(ReadyTimeout)
+	ready := false
+	for {
+		select {
+		case err := <-exited:
+			if err == nil {
+				err = errors.New("the server quit")
+			}
+			fmt.Fprintf(logFile, "=== %v\n", err)
+			return err
+		case <-s.stop:
+			terminate(cmd, exited)
+			fmt.Fprintf(logFile, "=== the server was stopped\n")
+			return nil
+		case <-deadline:
+			if !ready {
+				terminate(cmd, exited)
+				err := fmt.Errorf("the server wasn't ready after %v", ReadyTimeout)
+				fmt.Fprintf(logFile, "=== %v\n", err)
+				return err
+			}
+		case <-ticker.C:
+			if !ready && s.check() == nil {
+				ready = true
+				s.setStatus(Ready, nil)
+			}
+		}
+	}
+}
+
+// terminate asks the server to quit, and kills it if it doesn't quit in time.
+func terminate(cmd *exec.Cmd, exited chan error) {
+	// Interrupting a process isn't possible on every system (e.g on windows): then we just kill it.
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		cmd.Process.Kill()
+	}
+	select {
+	case <-exited:
+	case <-time.After(StopTimeout):
+		cmd.Process.Kill()
+		<-exited
+	}
+}
+
+/*
+ReadLog function:
+input: a language.
+output: the last LogTailLength lines of the log of its server, and a possible error.
+*/
+
+func ReadLog(language string) ([]string, error) {
+	file, err := os.Open(LogPath(language))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > LogTailLength {
+			lines = lines[1:]
+		}
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,281 @@
+/*
+	=====================================================================
+
+** spacedRepetition package **
+This package is responsible for the review sessions of the words we are
+learning (i.e the words we marked with level 1 or 2 while reading).
+The words are scheduled with the SM-2 algorithm (the same family of
+algorithms used by anki and supermemo): every word gets a due date, an
+interval and an ease factor, and every time we review a word these values
+are updated according to how well we remembered it.
+When the interval of a word becomes long enough the word "graduates",
+and it is promoted to level 3 (know well).
+
+    =====================================================================
+*/
+
+package spacedRepetition
+
+/*
+Imported packages:
+1) encoding/json --> the review data is stored on disk as json, like the word levels.
+2) fmt --> used to format the path of the review file
+3) math --> used to round the intervals
+4) os --> used to read the review file
+5) sort --> used to sort the cards by due date
+6) time --> used to deal with due dates
+7) vocabulary --> used to normalize the words of the cards
+8) atomicFile --> used to save the deck without ever leaving a half-written file
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"example.com/packages/atomicFile"
+	"example.com/packages/vocabulary"
+)
+
+// Grades that we can give to a card when reviewing it.
+// They are mapped to the SM-2 quality of the response (which goes from 0 to 5).
+const (
+	GradeAgain = iota // we didn't remember the word at all
+	GradeHard         // we remembered it, but with a lot of effort
+	GradeGood         // we remembered it
+	GradeEasy         // we remembered it instantly
+)
+
+// gradeQuality maps our 4 grades to the SM-2 quality of the response.
+var gradeQuality = []int{1, 3, 4, 5}
+
+const (
+	// DefaultEase is the ease factor a new card starts with.
+	DefaultEase = 2.5
+	// MinimumEase is the lowest ease factor allowed by SM-2.
+	MinimumEase = 1.3
+	// GraduationInterval is the interval (in days) after which a word is considered known
+	// and gets promoted to level 3.
+	GraduationInterval = 21
+)
+
+/*
+Review struct:
+a single entry in the review history of a card.
+*/
+
+type Review struct {
+	Date     time.Time `json:"date"`     // When the review happened
+	Grade    int       `json:"grade"`    // The grade we gave (GradeAgain, GradeHard, GradeGood or GradeEasy)
+	Interval int       `json:"interval"` // The interval (in days) that was scheduled after this review
+	Ease     float64   `json:"ease"`     // The ease factor after this review
+}
+
+/*
+Card struct:
+it stores the scheduling data of a single word.
+*/
+
+type Card struct {
+	Word        string    `json:"word"`        // The word being reviewed
+	Due         time.Time `json:"due"`         // When the word has to be reviewed again
+	Interval    int       `json:"interval"`    // The current interval in days
+	Ease        float64   `json:"ease"`        // The current ease factor
+	Repetitions int       `json:"repetitions"` // Number of consecutive successful reviews
+	Graduated   bool      `json:"graduated"`   // True once the word reached the graduation interval
+	History     []Review  `json:"history"`     // All the reviews of this word
+}
+
+/*
+Deck struct:
+the collection of the cards for a language.
+*/
+
+type Deck struct {
+	Cards   map[string]*Card `json:"cards"`
+	loadErr error            // If the review file couldn't be loaded, we refuse to overwrite it.
+}
+
+// deckPath returns the location of the review file of a language.
+func deckPath(language string) string {
+	return fmt.Sprintf("languages/%s/review.json", language)
+}
+
+/*
+LoadDeck function:
+input: language (string)
+output: a pointer to the Deck of the language, and a possible error.
+If the review file doesn't exist yet an empty deck is returned.
+If the review file can't be loaded, an empty deck is returned alongside the error:
+this deck will never overwrite the review history on disk (see Save).
+*/
+
+func LoadDeck(language string) (*Deck, error) {
+	deck := &Deck{Cards: map[string]*Card{}}
+	content, err := os.ReadFile(deckPath(language))
+	if os.IsNotExist(err) {
+		return deck, nil
+	}
+	if err != nil {
+		deck.loadErr = err
+		return deck, err
+	}
+	if err := json.Unmarshal(content, deck); err != nil {
+		return &Deck{Cards: map[string]*Card{}, loadErr: err}, err
+	}
+	if deck.Cards == nil {
+		deck.Cards = map[string]*Card{}
+	}
+	deck.normalize()
+	return deck, nil
+}
+
+/*
+normalize method:
+the vocabulary stores the words under their normalized key (see vocabulary.Key),
+so the cards created before that are moved under the same key. If two cards end up
+under the same key, we keep the one with the longest history.
+*/
+
+func (d *Deck) normalize() {
+	normalized := make(map[string]*Card, len(d.Cards))
+	for word, card := range d.Cards {
+		key := vocabulary.Key(word)
+		if key == "" {
+			continue
+		}
+		if existing, ok := normalized[key]; ok && len(existing.History) >= len(card.History) {
+			continue
+		}
+		card.Word = key
+		normalized[key] = card
+	}
+	d.Cards = normalized
+}
+
+/*
+Save method:
+writes the deck in the review file of the language (unless the file couldn't be loaded, see LoadDeck).
+The deck is written through a temporary file (see atomicFile), so a crash while saving never leaves
+a half-written review history.
+*/
+
+func (d *Deck) Save(language string) error {
+	if d.loadErr != nil {
+		return fmt.Errorf("not saving the review history of %s, it could not be loaded: %w", language, d.loadErr)
+	}
+	jsonData, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return atomicFile.Write(deckPath(language), jsonData)
+}
+
+/*
+Sync method:
+input: the word levels of the language and the current time.
+This method makes sure that the deck contains a card for every word marked
+with level 1 or 2. New cards are due immediately.
+The cards of the words that are not marked with level 1 or 2 anymore are kept
+(so that we don't lose their history), but they won't be shown by DueWords.
+If a graduated word has been marked again as not known, its card starts over.
+*/
+
+func (d *Deck) Sync(levels map[string]int, now time.Time) {
+	for word, level := range levels {
+		if level != 1 && level != 2 {
+			continue
+		}
+		card, ok := d.Cards[word]
+		if !ok {
+			d.Cards[word] = &Card{Word: word, Due: now, Ease: DefaultEase}
+		} else if card.Graduated {
+			card.Graduated = false
+			card.Repetitions = 0
+			card.Interval = 0
+			card.Due = now
+		}
+	}
+}
+
+/*
+DueWords method:
+input: the word levels of the language and the current time.
+output: the words that have to be reviewed at the time now, sorted by due date
+(the most overdue words come first).
+*/
+
+func (d *Deck) DueWords(levels map[string]int, now time.Time) []string {
+	var due []*Card
+	for word, card := range d.Cards {
+		if level := levels[word]; level != 1 && level != 2 {
+			continue
+		}
+		if !card.Graduated && !card.Due.After(now) {
+			due = append(due, card)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].Due.Equal(due[j].Due) {
+			return due[i].Word < due[j].Word
+		}
+		return due[i].Due.Before(due[j].Due)
+	})
+	words := make([]string, len(due))
+	for i, card := range due {
+		words[i] = card.Word
+	}
+	return words
+}
+
+/*
+Grade method:
+input: the word we reviewed, the grade we gave and the time of the review.
+output: true if the word graduated (i.e it should be promoted to level 3).
+
+This is where the SM-2 algorithm is implemented:
+- if we failed the card, the repetitions restart and the word is due tomorrow.
+- otherwise the interval goes 1 day -> 6 days -> previous interval * ease.
+- the ease factor is adjusted according to the quality of the response.
+*/
+
+func (d *Deck) Grade(word string, grade int, now time.Time) bool {
+	card, ok := d.Cards[word]
+	if !ok || grade < GradeAgain || grade > GradeEasy {
+		return false
+	}
+	quality := gradeQuality[grade]
+
+	if quality < 3 {
+		card.Repetitions = 0
+		card.Interval = 1
+	} else {
+		switch card.Repetitions {
+		case 0:
+			card.Interval = 1
+		case 1:
+			card.Interval = 6
+		default:
+			card.Interval = int(math.Round(float64(card.Interval) * card.Ease))
+		}
+		card.Repetitions++
+	}
+
+	card.Ease += 0.1 - float64(5-quality)*(0.08+float64(5-quality)*0.02)
+	if card.Ease < MinimumEase {
+		card.Ease = MinimumEase
+	}
+
+	card.Due = now.AddDate(0, 0, card.Interval)
+	card.History = append(card.History, Review{Date: now, Grade: grade, Interval: card.Interval, Ease: card.Ease})
+
+	if card.Interval >= GraduationInterval {
+		// The word graduated: we don't need to review it anymore.
+		card.Graduated = true
+		return true
+	}
+	return false
+}
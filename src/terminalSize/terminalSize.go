@@ -39,39 +39,26 @@ func GetTerminalSize() (int, int) {
 
 /*
     =====================================================================
-GetWordsPerLine function:
-input: none
-output: int (the number of words per line)
-This function calculates the appropriate number of words per line in order
-to give a good user experience to the user, since it does so while taking
-into account the size of the terminal (in this case we are interested solely
-in the width).
+LineWidth function:
+input: int (the width of the terminal)
+output: int (the width of a line, in terminal cells)
+This function returns how many cells of the terminal a line of text can
+take: the text reader packs as many words as fit in this width on each line.
+If we don't know the size of the terminal, we use the classic 80 columns.
 
 */
 
-func GetWordsPerLine() int {
-	// Get the width and ignore the height.
-	width, _ := GetTerminalSize()
-	// Some if/else clauses involving the width.
-	if width < 50 {
-		// If the width is less than 50, the number of words per line will be 5, i.e each line in the text reader will have 5 words.
-		return 5
-	} else if width < 80 {
-		// the logic is the same as above
-		return 7
-	} else if width < 100 {
-		// the logic is the same as above
-		return 10
-	} else {
-		// the logic is the same as above
-		return 15
+func LineWidth(width int) int {
+	if width <= 0 {
+		return 80
 	}
+	return width
 }
 
 /*
     =====================================================================
-GetLinesPerPage function:
-input: none
+LinesPerPage function:
+input: int (the height of the terminal)
 output: int (the number of lines per page)
 This function calculates the appropriate number of lines per page in order
 to give a good user experience to the user, since it does so while taking
@@ -80,10 +67,7 @@ in the height).
 
 */
 
-func GetLinesPerPage() int {
-	// Get current height of the terminal (ignore the width).
-	_, height := GetTerminalSize()
-
+func LinesPerPage(height int) int {
 	// Some if/else clauses involving the height
 	if height < 12 {
 		// If the height is less than 12, the number of lines per page will be 5, i.e each page in the text reader will have 5 lines.
@@ -0,0 +1,291 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/packages/atomicFile"
+	"example.com/packages/config"
+	"example.com/packages/vocabulary"
+)
+
+// DefaultCacheTTL is how long a cached translation is used before asking the provider again (see config.TranslationCacheTTL).
+const DefaultCacheTTL = 30 * 24 * time.Hour
+
+// CacheFlushDelay is how long a new translation waits before the cache is written on disk: all the translations
+// we get in the meantime (e.g while making a dictionary file) are written at once.
+const CacheFlushDelay = 2 * time.Second
+
+// CachePath returns the path of the translation cache of a language.
+func CachePath(language string) string {
+	return fmt.Sprintf("languages/%s/translations.json", language)
+}
+
+/*
+CachedTranslation struct:
+a translation we got from a provider, and when we got it.
+*/
+
+type CachedTranslation struct {
+	Translation string    `json:"translation"`
+	Time        time.Time `json:"time"`
+}
+
+/*
+TranslationCache struct:
+the translations of the words of a language we already got, stored in languages/<language>/translations.json.
+Every translation is keyed by the language of the word, the language of the translation, the provider and the
+word (normalized like the vocabulary does, see vocabulary.Key), and it is used until it's older than the TTL.
+The pinned translations (Pinned) are the ones the user chose: they never expire and they are used for the
+word whatever the provider is.
+A new translation only marks the cache as dirty: the file is written CacheFlushDelay later (see Flush), while
+a pinned translation is written right away.
+*/
+
+type TranslationCache struct {
+	path    string
+	ttl     time.Duration
+	loadErr error // If the file couldn't be read, we refuse to overwrite it (it may contain pinned translations).
+
+	mu       sync.Mutex                   // protects the fields below
+	dirty    bool                         // whether there are translations that aren't on disk yet
+	timer    *time.Timer                  // the pending flush (nil if there's none)
+	writeErr error                        // the error of the last write (if any)
+	Entries  map[string]CachedTranslation `json:"entries"`
+	Pinned   map[string]string            `json:"pinned"`
+}
+
+var (
+	caches   = map[string]*TranslationCache{}
+	cachesMu sync.Mutex
+)
+
+/*
+CacheFor function:
+input: a language.
+output: the translation cache of the language, and a possible error (if the file couldn't be read:
+the cache is empty then, and it never overwrites the file).
+The file is read the first time the cache is requested.
+*/
+
+func CacheFor(language string) (*TranslationCache, error) {
+	cachesMu.Lock()
+	defer cachesMu.Unlock()
+	if cache, ok := caches[language]; ok {
+		return cache, nil
+	}
+	cache := &TranslationCache{path: CachePath(language), ttl: cacheTTL(), Entries: map[string]CachedTranslation{}, Pinned: map[string]string{}}
+	caches[language] = cache
+	content, err := os.ReadFile(cache.path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err == nil {
+		err = json.Unmarshal(content, cache)
+	}
+	if cache.Entries == nil || err != nil {
+		cache.Entries = map[string]CachedTranslation{}
+	}
+	if cache.Pinned == nil || err != nil {
+		cache.Pinned = map[string]string{}
+	}
+	if err != nil {
+		cache.loadErr = fmt.Errorf("%s: %w", cache.path, err)
+		return cache, cache.loadErr
+	}
+	return cache, nil
+}
+
+// cacheTTL returns the TTL of the cached translations chosen in setup/config.json (0 means that they never expire).
+func cacheTTL() time.Duration {
+	setting := config.Get().TranslationCacheTTL
+	if setting == "" {
+		return DefaultCacheTTL
+	}
+	ttl, err := time.ParseDuration(setting)
+	if err != nil || ttl < 0 {
+		return DefaultCacheTTL
+	}
+	return ttl
+}
+
+// cacheKey builds the key of a translation in the cache.
+func cacheKey(parts ...string) string {
+	return strings.Join(parts, "|")
+}
+
+/*
+Get method:
+input: the codes of the language of the text and of the translation, the name of the provider and the text.
+output: the cached translation, and whether there is one that hasn't expired.
+*/
+
+func (c *TranslationCache) Get(source string, target string, provider string, text string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.Entries[cacheKey(source, target, provider, vocabulary.Key(text))]
+	if !ok || (c.ttl > 0 && time.Since(cached.Time) > c.ttl) {
+		return "", false
+	}
+	return cached.Translation, true
+}
+
+/*
+Put method:
+input: the codes of the language of the text and of the translation, the name of the provider, the text and its translation.
+output: a possible error (the one of the last write on disk, since the cache is written later, see Flush).
+*/
+
+func (c *TranslationCache) Put(source string, target string, provider string, text string, translation string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Entries[cacheKey(source, target, provider, vocabulary.Key(text))] = CachedTranslation{Translation: translation, Time: time.Now()}
+	if c.loadErr != nil {
+		return c.refuse()
+	}
+	c.dirty = true
+	if c.timer == nil {
+		c.timer = time.AfterFunc(CacheFlushDelay, func() {
+			c.Flush()
+		})
+	}
+	return c.writeErr
+}
+
+// Flush writes the translations that aren't on disk yet right now.
+func (c *TranslationCache) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	if c.loadErr != nil {
+		return c.refuse()
+	}
+	if !c.dirty {
+		return nil
+	}
+	return c.save()
+}
+
+// refuse returns the error we give when we can't save a cache whose file couldn't be read.
+func (c *TranslationCache) refuse() error {
+	return fmt.Errorf("not saving the translation cache, it could not be read: %w", c.loadErr)
+}
+
+/*
+PinnedTranslation method:
+input: the codes of the language of the text and of the translation, and the text.
+output: the translation the user pinned for the text, and whether there is one.
+*/
+
+func (c *TranslationCache) PinnedTranslation(source string, target string, text string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	translation, ok := c.Pinned[cacheKey(source, target, vocabulary.Key(text))]
+	return translation, ok
+}
+
+/*
+Pin method:
+input: the codes of the language of the text and of the translation, the text and the translation the user chose
+(an empty translation removes the pinned one).
+output: a possible error while saving the cache.
+*/
+
+func (c *TranslationCache) Pin(source string, target string, text string, translation string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.loadErr != nil {
+		return c.refuse()
+	}
+	key := cacheKey(source, target, vocabulary.Key(text))
+	if translation == "" {
+		delete(c.Pinned, key)
+	} else {
+		c.Pinned[key] = translation
+	}
+	return c.save()
+}
+
+// save writes the cache on disk (through a temporary file, see atomicFile, so that it is never left half written).
+// If the write fails, the cache stays dirty, so that it's written again with the next flush.
+func (c *TranslationCache) save() error {
+	c.writeErr = c.write()
+	c.dirty = c.writeErr != nil
+	return c.writeErr
+}
+
+func (c *TranslationCache) write() error {
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	return atomicFile.Write(c.path, content)
+}
+
+/*
+Pinned function:
+input: the language we're studying, a text and the codes of its language and of the language of the translation.
+output: the translation the user pinned for the text (see TranslationCache), and whether there is one.
+*/
+
+func Pinned(language string, text string, languageId string, bootLanguage string) (string, bool) {
+	cache, _ := CacheFor(language)
+	return cache.PinnedTranslation(languageId, bootLanguage, text)
+}
+
+/*
+Pin function:
+input: the language we're studying, a text, the codes of its language and of the language of the translation,
+and the translation to use for the text from now on (an empty translation removes the pinned one).
+output: a possible error.
+*/
+
+func Pin(language string, text string, languageId string, bootLanguage string, translation string) error {
+	cache, _ := CacheFor(language)
+	return cache.Pin(languageId, bootLanguage, text, translation)
+}
+
+/*
+FlushCaches function:
+output: the first error we got.
+It writes the translations of all the caches that aren't on disk yet; it is called when the application quits.
+*/
+
+func FlushCaches() error {
+	cachesMu.Lock()
+	defer cachesMu.Unlock()
+	var firstErr error
+	for _, cache := range caches {
+		// The caches whose file couldn't be read were never changed on disk, there's nothing to report.
+		if cache.loadErr != nil {
+			continue
+		}
+		if err := cache.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+/*
+FlushCache function:
+input: the language we're studying.
+output: a possible error.
+It writes the translations of the cache of the language that aren't on disk yet, and waits until they are.
+*/
+
+func FlushCache(language string) error {
+	cache, _ := CacheFor(language)
+	return cache.Flush()
+}
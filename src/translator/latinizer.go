@@ -2,6 +2,7 @@ package translator
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -431,36 +432,57 @@ func LatinizeChinese(text string, data map[string][]string) string {
 }
 
 func LatinizeJapanese(text string) string {
-	var output string
+	output, err := LatinizeJapaneseContext(context.Background(), text)
+	if err != nil {
+		return err.Error()
+	}
+	return output
+}
+
+/*
+LatinizeJapaneseContext function:
+it works like LatinizeJapanese, but the request stops when the context is cancelled, and the errors
+are returned apart from the latinization (so that they are never taken for one).
+*/
+
+func LatinizeJapaneseContext(ctx context.Context, text string) (string, error) {
 	url := "https://japonesbasico.com/furigana/procesa.php"
 	// This is the data that will be sent in the request body:
 	data := []byte(fmt.Sprintf(`{"conversion":"romaji", "japaneseText":"%s", "lang":"en"}`, text))
 
 	// Make the HTTP POST request
-	response, err := http.Post(url, "application/json", bytes.NewBuffer(data))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(data))
 	if err != nil {
-		return err.Error()
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return "", err
 	}
 	defer response.Body.Close()
 
 	// Check if the response status code is 200 OK
 	if response.StatusCode != http.StatusOK {
-		return fmt.Sprintf("Unexpected status code: %d\n", response.StatusCode)
+		return "", fmt.Errorf("unexpected status code: %d", response.StatusCode)
 	}
 
 	// Read the response body
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
-		return fmt.Sprintf("Error reading response body: %s", err.Error())
+		return "", fmt.Errorf("error reading response body: %w", err)
 	}
 	doc, err := html.Parse(strings.NewReader(string(body)))
 	if err != nil {
-		return err.Error()
+		return "", err
 	}
 
-	// Find and print the value inside the second <td> element
-	output = findSecondTdValue(doc)
-	return output
+	// Find the value inside the second <td> element
+	output := strings.TrimSpace(findSecondTdValue(doc))
+	if output == "" {
+		return "", fmt.Errorf("no latinization found for %s", text)
+	}
+	return output, nil
 }
 
 // Latinization of Arabic
@@ -710,6 +732,33 @@ Input: 1) the text we want to latinize (string)
 */
 
 func LatinizeText(text string, data map[string][]string, language string) string {
+	output, err := LatinizeTextContext(context.Background(), text, data, language)
+	if err != nil {
+		return err.Error()
+	}
+	return output
+}
+
+/*
+LatinizeTextContext function:
+it works like LatinizeText, but the requests (only japanese needs one) stop when the context is cancelled,
+and their errors are returned apart from the latinization.
+*/
+
+func LatinizeTextContext(ctx context.Context, text string, data map[string][]string, language string) (string, error) {
+	if LatinizationOnline(language) {
+		return LatinizeJapaneseContext(ctx, text)
+	}
+	return latinizeOffline(text, data, language), nil
+}
+
+// LatinizationOnline tells if the latinization of a language needs a request (only japanese does, see LatinizeJapanese).
+func LatinizationOnline(language string) bool {
+	return language == "japanese"
+}
+
+// latinizeOffline latinizes a text of the languages that don't need any request (see LatinizeText).
+func latinizeOffline(text string, data map[string][]string, language string) string {
 	// Check what language we're studying
 	switch language {
 	case "chinese":
@@ -721,8 +770,6 @@ func LatinizeText(text string, data map[string][]string, language string) string
 	case "greek":
 		// Greek script latinization
 		return LatinizeGreek(text)
-	case "japanese":
-		return LatinizeJapanese(text)
 	case "korean":
 		return LatinizeKorean(text)
 	case "arabic":
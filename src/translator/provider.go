@@ -0,0 +1,439 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/packages/config"
+	"example.com/packages/dictionary"
+)
+
+/*
+TranslationProvider interface:
+a way of translating a text. Every language has a main provider (key 5) and an alternative one
+(key 9), chosen in setup/config.json (see ProvidersFor).
+*/
+
+type TranslationProvider interface {
+	// Translate translates a text from the source language to the target language (both are language codes, e.g "it");
+	// the requests to the provider stop when the context is cancelled.
+	Translate(ctx context.Context, text string, source string, target string) (string, error)
+	// Name describes the provider, e.g "google" or "libretranslate http://localhost:5000".
+	Name() string
+}
+
+/*
+AlignedTranslator interface:
+it is implemented by the providers that can tell which part of a translation corresponds
+to which part of the original text (see TranslateSentence).
+*/
+
+type AlignedTranslator interface {
+	TranslateAligned(ctx context.Context, text string, source string, target string) (SentenceTranslation, error)
+}
+
+// ErrNotFound is the error of the providers that don't know the text (e.g a word that isn't in the dictionary).
+var ErrNotFound = errors.New("no translation found")
+
+// DefaultLibreTranslateURL is the address of a LibreTranslate server running on our computer.
+const DefaultLibreTranslateURL = "http://localhost:5000"
+
+/*
+defaultTranslation variable:
+the providers LinGo uses when setup/config.json doesn't say anything about a language:
+google translate for the key 5, and mymemory for the key 9.
+*/
+
+var defaultTranslation = config.Translation{
+	Main:        []config.TranslationProvider{{Type: "google"}},
+	Alternative: []config.TranslationProvider{{Type: "mymemory"}},
+}
+
+// providerPair is the main and the alternative provider of a language.
+type providerPair struct {
+	main        TranslationProvider
+	alternative TranslationProvider
+}
+
+var (
+	providers   = map[string]providerPair{}
+	providersMu sync.Mutex
+)
+
+/*
+ProvidersFor function:
+input: a language.
+output: the main and the alternative translation provider of the language, and a possible error.
+The providers are chosen by the "translation" setting in setup/config.json (the language, or else
+"default"), otherwise by defaultTranslation; they are created once and then reused.
+*/
+
+func ProvidersFor(language string) (TranslationProvider, TranslationProvider, error) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	if pair, ok := providers[language]; ok {
+		return pair.main, pair.alternative, nil
+	}
+	settings, ok := config.Get().Translation[language]
+	if !ok {
+		settings, ok = config.Get().Translation["default"]
+	}
+	if !ok {
+		settings = defaultTranslation
+	}
+	if len(settings.Main) == 0 {
+		settings.Main = defaultTranslation.Main
+	}
+	if len(settings.Alternative) == 0 {
+		settings.Alternative = defaultTranslation.Alternative
+	}
+	main, err := NewChain(language, settings.Main)
+	if err != nil {
+		return nil, nil, fmt.Errorf("translation of %s: %w", language, err)
+	}
+	alternative, err := NewChain(language, settings.Alternative)
+	if err != nil {
+		return nil, nil, fmt.Errorf("translation of %s: %w", language, err)
+	}
+	providers[language] = providerPair{main: main, alternative: alternative}
+	return main, alternative, nil
+}
+
+/*
+TranslateFor function:
+input: a context (cancelling it stops the requests to the provider), the language we're studying, the text, the codes
+of the language of the text and of the language we want it translated in, and whether we want the alternative translation (key 9).
+output: the translation, and a string containing a possible error (like Translate and Translate2).
+It works like TranslateWord, for the code that handles the errors as strings.
+*/
+
+func TranslateFor(ctx context.Context, language string, text string, languageId string, bootLanguage string, alternative bool) (string, string) {
+	translation, err := TranslateWord(ctx, language, text, languageId, bootLanguage, alternative)
+	if err != nil {
+		return translation, err.Error()
+	}
+	return translation, ""
+}
+
+/*
+TranslateWord function:
+input: a context (cancelling it stops the requests to the provider), the language we're studying, the text, the codes
+of the language of the text and of the language we want it translated in, and whether we want the alternative translation (key 9).
+output: the translation, and a possible error (see NotFound). If the translation was found but the cache couldn't
+be saved, both are returned.
+The translation cache of the language (see cache.go) is consulted first: the translation pinned by the user
+(only for the main translation), then the one already given by the provider, if it hasn't expired.
+*/
+
+func TranslateWord(ctx context.Context, language string, text string, languageId string, bootLanguage string, alternative bool) (string, error) {
+	main, other, err := ProvidersFor(language)
+	if err != nil {
+		return "", err
+	}
+	provider := main
+	if alternative {
+		provider = other
+	}
+	// The cache is still usable when its file couldn't be read (it's empty then).
+	cache, _ := CacheFor(language)
+	if !alternative {
+		if pinned, ok := cache.PinnedTranslation(languageId, bootLanguage, text); ok {
+			return pinned, nil
+		}
+	}
+	if cached, ok := cache.Get(languageId, bootLanguage, provider.Name(), text); ok {
+		return cached, nil
+	}
+	translation, err := provider.Translate(ctx, text, languageId, bootLanguage)
+	if err == nil && translation == "" {
+		err = ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	// The translation is still good if the cache can't be saved: it is returned with the error.
+	if err := cache.Put(languageId, bootLanguage, provider.Name(), text, translation); err != nil {
+		return translation, err
+	}
+	return translation, nil
+}
+
+/*
+Cached function:
+input: the language we're studying, the text, and the codes of the language of the text and of the language of the translation.
+output: the main translation of the text we already have (the pinned one, or the one in the cache, if it hasn't expired),
+and whether there is one: if there is, TranslateWord doesn't need to ask the provider.
+*/
+
+func Cached(language string, text string, languageId string, bootLanguage string) (string, bool) {
+	main, _, err := ProvidersFor(language)
+	if err != nil {
+		return "", false
+	}
+	cache, _ := CacheFor(language)
+	if pinned, ok := cache.PinnedTranslation(languageId, bootLanguage, text); ok {
+		return pinned, true
+	}
+	return cache.Get(languageId, bootLanguage, main.Name(), text)
+}
+
+/*
+NotFound function:
+input: an error of a provider.
+output: true if the error only says that the providers don't know the text (so asking them again is useless),
+false if at least one of them failed for another reason (e.g no connection).
+*/
+
+func NotFound(err error) bool {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs := joined.Unwrap()
+		for _, err := range errs {
+			if !NotFound(err) {
+				return false
+			}
+		}
+		return len(errs) > 0
+	}
+	return errors.Is(err, ErrNotFound)
+}
+
+/*
+NewChain function:
+input: a language and the settings of a list of providers.
+output: a provider that tries the providers of the list in order, until one of them
+translates the text (a list of a single provider is just the provider), and a possible error.
+*/
+
+func NewChain(language string, settings []config.TranslationProvider) (TranslationProvider, error) {
+	var chain chainProvider
+	for _, setting := range settings {
+		provider, err := NewProvider(language, setting)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, provider)
+	}
+	if len(chain) == 1 {
+		return chain[0], nil
+	}
+	return chain, nil
+}
+
+/*
+NewProvider function:
+input: a language and the settings of a provider.
+output: the provider, and a possible error.
+*/
+
+func NewProvider(language string, settings config.TranslationProvider) (TranslationProvider, error) {
+	switch settings.Type {
+	case "google":
+		return googleProvider{}, nil
+	case "mymemory":
+		return myMemoryProvider{}, nil
+	case "libretranslate":
+		address := settings.URL
+		if address == "" {
+			address = DefaultLibreTranslateURL
+		}
+		return libreTranslateProvider{url: strings.TrimSuffix(address, "/"), apiKey: settings.APIKey}, nil
+	case "dictionary":
+		return &dictionaryProvider{language: language, files: settings.Files}, nil
+	default:
+		return nil, fmt.Errorf("unknown translation provider %q", settings.Type)
+	}
+}
+
+// googleProvider uses google translate (see Translate2).
+type googleProvider struct{}
+
+func (googleProvider) Name() string {
+	return "google"
+}
+
+func (googleProvider) Translate(ctx context.Context, text string, source string, target string) (string, error) {
+	translation, errString := Translate2Context(ctx, text, source, target)
+	if errString != "" {
+		return "", errors.New(errString)
+	}
+	return translation, nil
+}
+
+func (googleProvider) TranslateAligned(ctx context.Context, text string, source string, target string) (SentenceTranslation, error) {
+	return translateAligned(ctx, text, source, target)
+}
+
+// myMemoryProvider uses the mymemory API (see Translate).
+type myMemoryProvider struct{}
+
+func (myMemoryProvider) Name() string {
+	return "mymemory"
+}
+
+func (myMemoryProvider) Translate(ctx context.Context, text string, source string, target string) (string, error) {
+	translation, errString := TranslateContext(ctx, text, source, target)
+	if errString != "" {
+		return "", errors.New(errString)
+	}
+	return translation, nil
+}
+
+// libreTranslateProvider uses a LibreTranslate server (https://github.com/LibreTranslate/LibreTranslate).
+type libreTranslateProvider struct {
+	url    string
+	apiKey string
+}
+
+// libreTranslateClient is the http client used to talk with the LibreTranslate servers.
+var libreTranslateClient = &http.Client{Timeout: 30 * time.Second}
+
+func (p libreTranslateProvider) Name() string {
+	return "libretranslate " + p.url
+}
+
+func (p libreTranslateProvider) Translate(ctx context.Context, text string, source string, target string) (string, error) {
+	request := map[string]string{"q": text, "source": source, "target": target, "format": "text"}
+	if p.apiKey != "" {
+		request["api_key"] = p.apiKey
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url+"/translate", bytes.NewBuffer(data))
+	if err != nil {
+		return "", err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	response, err := libreTranslateClient.Do(httpRequest)
+	if err != nil {
+		return "", fmt.Errorf("can't reach the LibreTranslate server: %w", err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+	var res struct {
+		TranslatedText string `json:"translatedText"`
+		Error          string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return "", fmt.Errorf("can't parse the answer of the LibreTranslate server: %w", err)
+	}
+	if res.Error != "" {
+		return "", fmt.Errorf("LibreTranslate: %s", res.Error)
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code: %d", response.StatusCode)
+	}
+	return res.TranslatedText, nil
+}
+
+/*
+dictionaryProvider struct:
+it translates the words with the offline dictionaries (see the dictionary package), without any
+connection: the translation is the definition of the first entry, on a single line.
+The dictionaries are loaded the first time we need them.
+*/
+
+type dictionaryProvider struct {
+	language string
+	files    []string
+	once     sync.Once
+	library  *dictionary.Library
+	err      error
+}
+
+func (p *dictionaryProvider) Name() string {
+	return "dictionary"
+}
+
+func (p *dictionaryProvider) Translate(ctx context.Context, text string, source string, target string) (string, error) {
+	p.once.Do(func() {
+		if len(p.files) == 0 {
+			p.library, p.err = dictionary.ForLanguage(p.language)
+		} else {
+			p.library, p.err = dictionary.Open(p.files...)
+		}
+		// The dictionaries we couldn't read are reported only if there are none we can use.
+		if p.library != nil && len(p.library.Dictionaries) > 0 {
+			p.err = nil
+		}
+	})
+	if p.err != nil {
+		return "", p.err
+	}
+	entries, err := p.library.Lookup(text)
+	if len(entries) == 0 {
+		if err != nil {
+			return "", err
+		}
+		return "", ErrNotFound
+	}
+	// The dictionaries that split the senses (like CC-CEDICT and JMdict) give them directly.
+	if len(entries[0].Senses) > 0 {
+		return strings.Join(entries[0].Senses, "; "), nil
+	}
+	var lines []string
+	for k, line := range strings.Split(entries[0].Definition, "\n") {
+		// Some dictionaries (like the dictd ones) repeat the word on the first line of the definition.
+		if line = strings.TrimSpace(line); line != "" && (k != 0 || !strings.HasPrefix(line, entries[0].Headword)) {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "; "), nil
+}
+
+// chainProvider tries its providers in order, until one of them translates the text.
+type chainProvider []TranslationProvider
+
+func (c chainProvider) Name() string {
+	names := make([]string, len(c))
+	for k, provider := range c {
+		names[k] = provider.Name()
+	}
+	return strings.Join(names, ", ")
+}
+
+func (c chainProvider) Translate(ctx context.Context, text string, source string, target string) (string, error) {
+	var errs []error
+	for _, provider := range c {
+		// There's no point in asking the next providers if the lookup was cancelled.
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		translation, err := provider.Translate(ctx, text, source, target)
+		if err == nil && translation != "" {
+			return translation, nil
+		}
+		if err == nil {
+			err = ErrNotFound
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+	}
+	return "", errors.Join(errs...)
+}
+
+func (c chainProvider) TranslateAligned(ctx context.Context, text string, source string, target string) (SentenceTranslation, error) {
+	var errs []error
+	for _, provider := range c {
+		if err := ctx.Err(); err != nil {
+			return SentenceTranslation{}, err
+		}
+		translation, err := translateSentenceWith(ctx, provider, text, source, target)
+		if err == nil {
+			return translation, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+	}
+	return SentenceTranslation{}, errors.Join(errs...)
+}
@@ -0,0 +1,185 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+/*
+Alignment struct:
+a part of a sentence and the part of its translation that translates it (the positions are byte offsets).
+*/
+
+type Alignment struct {
+	SourceStart int
+	SourceEnd   int
+	TargetStart int
+	TargetEnd   int
+}
+
+/*
+SentenceTranslation struct:
+the translation of a whole sentence; Alignments is empty if the API didn't tell us which
+words of the translation correspond to which words of the sentence.
+*/
+
+type SentenceTranslation struct {
+	Source      string
+	Translation string
+	Alignments  []Alignment
+}
+
+// TargetOf returns the part of the translation that corresponds to the part of the sentence starting at
+// the given byte offset, or -1, -1 if we don't know it.
+func (t SentenceTranslation) TargetOf(sourceOffset int) (int, int) {
+	for _, alignment := range t.Alignments {
+		if sourceOffset >= alignment.SourceStart && sourceOffset < alignment.SourceEnd {
+			return alignment.TargetStart, alignment.TargetEnd
+		}
+	}
+	return -1, -1
+}
+
+/*
+alignedResponse struct:
+the part of the answer of the google translate API we are interested in: besides the translation
+(sentences), the API can send the alternative translations of every phrase of the text, together with
+the position of the phrase in the text: we use the first alternative to find where the phrase ended up
+in the translation.
+*/
+
+type alignedResponse struct {
+	Sentences []struct {
+		Trans string `json:"trans"`
+	} `json:"sentences"`
+	AlternativeTranslations []struct {
+		Alternative []struct {
+			WordPostproc string `json:"word_postproc"`
+		} `json:"alternative"`
+		SrcUnicodeOffsets []struct {
+			Begin int `json:"begin"`
+			End   int `json:"end"`
+		} `json:"srcunicodeoffsets"`
+	} `json:"alternative_translations"`
+}
+
+// sentenceClient is the http client used for the translations of the sentences.
+var sentenceClient = &http.Client{Timeout: 15 * time.Second}
+
+/*
+TranslateSentence function:
+input: a context (cancelling it stops the requests to the provider), the language we're studying, a sentence, the code
+of its language, the code of the language we want it translated in, and whether we want the alternative translation
+(the one of the key 9).
+output: the translation, and a string containing a possible error.
+If the provider of the language can align the translation with the sentence (like google translate
+does), we ask for the alignment too; if that request fails, we fall back to a plain translation.
+*/
+
+func TranslateSentence(ctx context.Context, language string, text string, languageId string, bootLanguage string, alternative bool) (SentenceTranslation, string) {
+	main, other, err := ProvidersFor(language)
+	if err != nil {
+		return SentenceTranslation{Source: text}, err.Error()
+	}
+	provider := main
+	if alternative {
+		provider = other
+	}
+	translation, err := translateSentenceWith(ctx, provider, text, languageId, bootLanguage)
+	if err != nil {
+		return SentenceTranslation{Source: text}, err.Error()
+	}
+	return translation, ""
+}
+
+// translateSentenceWith translates a sentence with a provider, with the alignment if the provider can give it.
+func translateSentenceWith(ctx context.Context, provider TranslationProvider, text string, source string, target string) (SentenceTranslation, error) {
+	if aligned, ok := provider.(AlignedTranslator); ok {
+		if translation, err := aligned.TranslateAligned(ctx, text, source, target); err == nil {
+			return translation, nil
+		}
+	}
+	translation, err := provider.Translate(ctx, text, source, target)
+	if err != nil {
+		return SentenceTranslation{}, err
+	}
+	return SentenceTranslation{Source: text, Translation: translation}, nil
+}
+
+// translateAligned asks google translate for the translation of a text and the alignment of its phrases.
+func translateAligned(ctx context.Context, text string, languageId string, bootLanguage string) (SentenceTranslation, error) {
+	query := url.Values{}
+	query.Set("client", "gtx")
+	query.Set("sl", languageId)
+	query.Set("tl", bootLanguage)
+	query.Set("dj", "1")
+	query.Add("dt", "t")
+	query.Add("dt", "at")
+	query.Set("q", text)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://translate.googleapis.com/translate_a/single?"+query.Encode(), nil)
+	if err != nil {
+		return SentenceTranslation{}, err
+	}
+	response, err := sentenceClient.Do(request)
+	if err != nil {
+		return SentenceTranslation{}, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return SentenceTranslation{}, fmt.Errorf("unexpected status code: %d", response.StatusCode)
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return SentenceTranslation{}, err
+	}
+	var res alignedResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return SentenceTranslation{}, err
+	}
+
+	output := SentenceTranslation{Source: text}
+	for _, sentence := range res.Sentences {
+		output.Translation += sentence.Trans
+	}
+	if output.Translation == "" {
+		return SentenceTranslation{}, fmt.Errorf("empty translation")
+	}
+	// The phrases are translated in order: we look for every one of them after the previous one.
+	target := 0
+	for _, phrase := range res.AlternativeTranslations {
+		if len(phrase.Alternative) == 0 || len(phrase.SrcUnicodeOffsets) == 0 {
+			continue
+		}
+		translated := strings.TrimSpace(phrase.Alternative[0].WordPostproc)
+		index := strings.Index(output.Translation[target:], translated)
+		if translated == "" || index == -1 {
+			continue
+		}
+		offsets := phrase.SrcUnicodeOffsets[0]
+		output.Alignments = append(output.Alignments, Alignment{
+			SourceStart: byteOffset(text, offsets.Begin),
+			SourceEnd:   byteOffset(text, offsets.End),
+			TargetStart: target + index,
+			TargetEnd:   target + index + len(translated),
+		})
+		target += index + len(translated)
+	}
+	return output, nil
+}
+
+// byteOffset converts an offset in characters (as the API gives them) in an offset in bytes.
+func byteOffset(text string, characters int) int {
+	offset := 0
+	for k := 0; k < characters && offset < len(text); k++ {
+		_, size := utf8.DecodeRuneInString(text[offset:])
+		offset += size
+	}
+	return offset
+}
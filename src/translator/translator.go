@@ -4,7 +4,9 @@
 ** translator package **
 This package is responsible for the translation of words in the text we
 are going to study. It does so by communicating with an API through http
-requests (the mymemory translation API).
+requests (the mymemory translation API, google translate or a LibreTranslate
+server) or by looking the words up in local dictionaries: every language
+chooses its translation providers in setup/config.json (see provider.go).
 
     =====================================================================
 */
@@ -19,15 +21,21 @@ transform some of the data of our application into JSON format for using it for
 4) net/http --> used to make the http requests to the API.
 5) net/url --> used to transform the words of languages which do not use the latin alphabet (like russian,
 ukrainian, mongolian,kazakh, standard arabic, korean, chinese, greek exc.) into url encoded format.
+6) html, sort and strings --> used to clean up and rank the alternative translations of the mymemory API.
+7) context --> used to stop the requests when the user doesn't want the translation anymore.
 
 */
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"html"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sort"
+	"strings"
 
 	translator "github.com/Conight/go-googletrans"
 )
@@ -85,47 +93,96 @@ the target language.
 */
 
 func Translate(text string, languageId string, bootLanguage string) (string, string) {
+	return TranslateContext(context.Background(), text, languageId, bootLanguage)
+}
+
+/*
+TranslateContext function:
+it works like Translate, but the request to the API stops when the context is cancelled (or its deadline passes).
+*/
+
+func TranslateContext(ctx context.Context, text string, languageId string, bootLanguage string) (string, string) {
+	res, errString := queryMyMemory(ctx, text, languageId, bootLanguage)
+	if errString != "" {
+		return "", errString
+	}
+	return html.UnescapeString(res.ResponseData.TranslatedText), ""
+}
+
+/*
+TranslateMatches function:
+input: a context (cancelling it stops the request), the text in the original language, and the codes of its language
+and of the language of the translation.
+output: the translations of the text found by the mymemory API (the main one and its matches), ranked by their
+score (Match, from 0 to 1), and a string containing a possible error.
+The same translation is listed only once, with its best score.
+*/
+
+func TranslateMatches(ctx context.Context, text string, languageId string, bootLanguage string) ([]Match, string) {
+	res, errString := queryMyMemory(ctx, text, languageId, bootLanguage)
+	if errString != "" {
+		return nil, errString
+	}
+	main := Match{Segment: text, Translation: res.ResponseData.TranslatedText, Source: languageId, Target: bootLanguage, Match: res.ResponseData.Match}
+	var matches []Match
+	seen := map[string]int{}
+	for _, match := range append([]Match{main}, res.Matches...) {
+		match.Translation = strings.TrimSpace(html.UnescapeString(match.Translation))
+		if match.Translation == "" {
+			continue
+		}
+		key := strings.ToLower(match.Translation)
+		if k, ok := seen[key]; ok {
+			matches[k].Match = max(matches[k].Match, match.Match)
+			continue
+		}
+		seen[key] = len(matches)
+		matches = append(matches, match)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Match > matches[j].Match
+	})
+	return matches, ""
+}
+
+// queryMyMemory asks the mymemory API for the translations of a text; it returns its answer and a string containing a possible error.
+func queryMyMemory(ctx context.Context, text string, languageId string, bootLanguage string) (Response, string) {
+	var res Response
 	// This piece of code encodes the text in url encoding (since it might possibly be a not valid url)
-	encodedText := url.QueryEscape(text) + "%s"
+	encodedText := url.QueryEscape(text)
 	// The url to which we will perform the get request.
 	apiurl := fmt.Sprintf("https://api.mymemory.translated.net/get?q=%s&langpair=%s|%s", encodedText, languageId, bootLanguage)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, apiurl, nil)
+	if err != nil {
+		return res, fmt.Sprintf("Error making GET request: %s", err.Error())
+	}
 	// Response and error object originating from the request to the above url.
-	response, err := http.Get(apiurl)
+	response, err := http.DefaultClient.Do(request)
 	// If there is an error, print it out to the console.
 	if err != nil {
-		return "", fmt.Sprintf("Error making POST request: %s", err.Error())
+		return res, fmt.Sprintf("Error making POST request: %s", err.Error())
 	}
 	// We are going to close the response.Body eventually and we defer it here to the end.
 	defer response.Body.Close()
 
 	// Check if the response status code is 200 OK
 	if response.StatusCode != http.StatusOK {
-		return "", fmt.Sprintf("Unexpected status code: %d", response.StatusCode)
+		return res, fmt.Sprintf("Unexpected status code: %d", response.StatusCode)
 	}
 
 	// Read the response body
 	body, err2 := ioutil.ReadAll(response.Body)
 	if err2 != nil {
-		return "", fmt.Sprintf("Error reading response body: %s", err2.Error())
+		return res, fmt.Sprintf("Error reading response body: %s", err2.Error())
 	}
 
-	// Initialize a variable res of type Response, to store in the output json as a struct.
-	var res Response
 	// Parse the json we received as a response
 	err3 := json.Unmarshal(body, &res)
 
 	if err3 != nil {
-		return "", fmt.Sprintf("Error parsing JSON: %s", err3.Error())
-	}
-
-	// Return the translation: we're doing a little case-checking to adjust the output
-	// for some problems regarding text encoding (since we might potentially have non-latin
-	// alphabet languages)
-	if len(res.ResponseData.TranslatedText) >= 2 {
-		return res.ResponseData.TranslatedText[:len(res.ResponseData.TranslatedText)-2], ""
-	} else {
-		return res.ResponseData.TranslatedText, ""
+		return res, fmt.Sprintf("Error parsing JSON: %s", err3.Error())
 	}
+	return res, ""
 }
 
 func Translate2(text string, languageId string, bootLanguage string) (string, string) {
@@ -136,3 +193,18 @@ func Translate2(text string, languageId string, bootLanguage string) (string, st
 	}
 	return result.Text, ""
 }
+
+/*
+Translate2Context function:
+it works like Translate2, but the request stops when the context is cancelled (or its deadline passes).
+The go-googletrans library can't stop its requests, so we ask the google translate API ourselves, the same
+way we do for the sentences (see translateAligned).
+*/
+
+func Translate2Context(ctx context.Context, text string, languageId string, bootLanguage string) (string, string) {
+	translation, err := translateAligned(ctx, text, languageId, bootLanguage)
+	if err != nil {
+		return "", err.Error()
+	}
+	return translation.Translation, ""
+}
@@ -0,0 +1,146 @@
+package vocabulary
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"example.com/packages/atomicFile"
+)
+
+const (
+	// Backups is the number of old copies of words.json that we keep (words.json.1 is the most recent).
+	Backups = 3
+	// BackupInterval is the minimum time between two backups.
+	BackupInterval = 10 * time.Minute
+)
+
+/*
+Backend interface:
+a Backend is where the vocabulary of a language is persisted.
+
+- Load returns all the words stored.
+- Save persists the words; changed contains the words that were modified since the
+  last call (nil means all of them), so that the backends that can update a single
+  word don't have to write everything again.
+- Close releases the resources of the backend.
+*/
+
+type Backend interface {
+	Load() (map[string]*Entry, error)
+	Save(words map[string]*Entry, changed []string) error
+	Close() error
+}
+
+/*
+History interface:
+it is implemented by the backends that can also keep track of what we do while
+reading (the lookups of the words and the position we reached in every text).
+*/
+
+type History interface {
+	RecordLookup(word string, kind string, at time.Time) error
+	SaveProgress(text string, position int) error
+	Progress(text string) (int, error)
+	WordsSeenSince(level int, since time.Time) ([]string, error)
+}
+
+/*
+jsonBackend struct:
+the default backend, which stores the vocabulary in languages/<language>/words.json.
+*/
+
+type jsonBackend struct {
+	language string
+}
+
+/*
+Load method:
+If the words.json file of the language was written with an older schema, it is
+migrated to the current one and rewritten in place (a copy of the old file is kept
+as words.v<old version>.json).
+*/
+
+func (b *jsonBackend) Load() (map[string]*Entry, error) {
+	content, err := os.ReadFile(Path(b.language))
+	if os.IsNotExist(err) {
+		return map[string]*Entry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file, version, err := Migrate(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != CurrentVersion {
+		// Keep the old file around, just in case.
+		backup := fmt.Sprintf("languages/%s/words.v%d.json", b.language, version)
+		if err := os.WriteFile(backup, content, 0666); err != nil {
+			return nil, err
+		}
+		if err := b.Save(file.Words, nil); err != nil {
+			return nil, err
+		}
+	}
+	return file.Words, nil
+}
+
+/*
+Save method:
+it rewrites the whole words.json file (json can't be updated one word at a time).
+To make sure that we never lose the vocabulary if the application dies while writing,
+the data is written through a temporary file, which then atomically replaces words.json
+(see atomicFile). Before that, the old file is copied in the rotating backups.
+*/
+
+func (b *jsonBackend) Save(words map[string]*Entry, changed []string) error {
+	jsonData, err := json.Marshal(File{Version: CurrentVersion, Words: words})
+	if err != nil {
+		return err
+	}
+	filename := Path(b.language)
+	if err := rotateBackups(filename); err != nil {
+		return err
+	}
+	return atomicFile.Write(filename, jsonData)
+}
+
+/*
+rotateBackups function:
+input: the path of the file we are about to replace.
+It shifts the backups (file.1 -> file.2 -> ... -> file.<Backups>) and copies the
+current file into file.1, unless the most recent backup is younger than BackupInterval.
+The current file is copied (and not renamed) so that there is never a moment in which
+the file doesn't exist.
+*/
+
+func rotateBackups(filename string) error {
+	current, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	newest := fmt.Sprintf("%s.1", filename)
+	if info, err := os.Stat(newest); err == nil && time.Since(info.ModTime()) < BackupInterval {
+		return nil
+	}
+	for i := Backups - 1; i >= 1; i-- {
+		older := fmt.Sprintf("%s.%d", filename, i)
+		if _, err := os.Stat(older); err == nil {
+			if err := os.Rename(older, fmt.Sprintf("%s.%d", filename, i+1)); err != nil {
+				return err
+			}
+		}
+	}
+	return os.WriteFile(newest, current, 0666)
+}
+
+func (b *jsonBackend) Close() error {
+	return nil
+}
@@ -0,0 +1,95 @@
+package vocabulary
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// folder is used to case-fold the words (it is the unicode equivalent of lower-casing).
+var folder = cases.Fold()
+
+/*
+Key function:
+input: a word as it appears in a text.
+output: the key under which the word is stored in the vocabulary.
+
+The key is the word in NFC normal form, case-folded and without leading or trailing
+punctuation, so that "Hello," "hello" and "HELLO" are all the same word.
+Punctuation inside the word (e.g the apostrophe in "don't") is kept.
+*/
+
+func Key(word string) string {
+	word = strings.TrimFunc(word, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSpace(r)
+	})
+	return norm.NFC.String(folder.String(norm.NFC.String(word)))
+}
+
+/*
+mergeEntries function:
+input: two entries that ended up under the same key.
+output: the merged entry.
+The higher level of knowledge wins, the first-seen date is the earliest one, and
+the empty fields of one entry are filled in with the fields of the other.
+*/
+
+func mergeEntries(a *Entry, b *Entry) *Entry {
+	if b.Level > a.Level {
+		a, b = b, a
+	}
+	merged := *a
+	if merged.Translation == "" {
+		merged.Translation = b.Translation
+	}
+	if merged.Meaning == "" {
+		merged.Meaning = b.Meaning
+	}
+	if merged.Latinization == "" {
+		merged.Latinization = b.Latinization
+	}
+	if merged.Notes == "" {
+		merged.Notes = b.Notes
+	} else if b.Notes != "" && b.Notes != merged.Notes {
+		merged.Notes += "\n" + b.Notes
+	}
+	if merged.FirstSeen == nil || (b.FirstSeen != nil && b.FirstSeen.Before(*merged.FirstSeen)) {
+		merged.FirstSeen = b.FirstSeen
+		if b.Source != "" {
+			merged.Source = b.Source
+			merged.Context = b.Context
+		}
+	}
+	if merged.Source == "" {
+		merged.Source = b.Source
+	}
+	if merged.Context == "" {
+		merged.Context = b.Context
+	}
+	return &merged
+}
+
+/*
+normalizeKeys function:
+input: the words of a vocabulary, keyed by their surface form.
+output: the same words keyed by Key(); the entries that collide are merged, and
+the entries whose key is empty (i.e they were only punctuation) are dropped.
+*/
+
+func normalizeKeys(words map[string]*Entry) map[string]*Entry {
+	normalized := make(map[string]*Entry, len(words))
+	for word, entry := range words {
+		key := Key(word)
+		if key == "" {
+			continue
+		}
+		if existing, ok := normalized[key]; ok {
+			normalized[key] = mergeEntries(existing, entry)
+		} else {
+			normalized[key] = entry
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,112 @@
+package vocabulary
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+/*
+Schema versions of the words.json file:
+
+1) the original format: a flat json object mapping every word to its level of knowledge.
+   {"hola": 1, "gracias": 3}
+2) a versioned object, where every word has an entry with its metadata.
+   {"version": 2, "words": {"hola": {"level": 1, "translation": "hello", ...}}}
+3) same structure as version 2, but the words are stored under their normalized key
+   (see Key in key.go), so "Hola," and "hola" are the same word.
+4) same structure as version 3, but an entry can have the meaning written by the user
+   ("meaning"): the version changes so that older versions of LinGo, which would drop it, refuse the file.
+
+Every migration takes the raw content of a file of version n and returns the
+content of the same file in version n+1.
+*/
+
+var migrations = map[int]func(content []byte) ([]byte, error){
+	1: migrateV1ToV2,
+	2: migrateV2ToV3,
+	3: migrateV3ToV4,
+}
+
+// versionOf detects the schema version of the content of a words.json file.
+func versionOf(content []byte) (int, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(content, &fields); err != nil {
+		return 0, err
+	}
+	// Version 1 files are flat maps of numbers, so they can't have an object under
+	// the "words" key (even if the text contained the word "words").
+	words, hasWords := fields["words"]
+	rawVersion, hasVersion := fields["version"]
+	if !hasWords || !hasVersion || len(words) == 0 || words[0] != '{' {
+		return 1, nil
+	}
+	var version int
+	if err := json.Unmarshal(rawVersion, &version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+/*
+Migrate function:
+input: the raw content of a words.json file.
+output: the parsed file in the current schema, the version the content was written in,
+and a possible error.
+*/
+
+func Migrate(content []byte) (*File, int, error) {
+	original, err := versionOf(content)
+	if err != nil {
+		return nil, 0, err
+	}
+	if original > CurrentVersion {
+		return nil, original, fmt.Errorf("words.json has version %d, but this version of LinGo only supports up to version %d", original, CurrentVersion)
+	}
+
+	for version := original; version < CurrentVersion; version++ {
+		content, err = migrations[version](content)
+		if err != nil {
+			return nil, original, fmt.Errorf("migrating words.json from version %d: %w", version, err)
+		}
+	}
+
+	var file File
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, original, err
+	}
+	if file.Words == nil {
+		file.Words = map[string]*Entry{}
+	}
+	return &file, original, nil
+}
+
+// migrateV1ToV2 turns the flat word -> level map into a versioned file with an entry per word.
+func migrateV1ToV2(content []byte) ([]byte, error) {
+	var levels map[string]int
+	if err := json.Unmarshal(content, &levels); err != nil {
+		return nil, err
+	}
+	words := make(map[string]*Entry, len(levels))
+	for word, level := range levels {
+		words[word] = &Entry{Level: level}
+	}
+	return json.Marshal(File{Version: 2, Words: words})
+}
+
+// migrateV2ToV3 stores the words under their normalized key, merging the ones that collide.
+func migrateV2ToV3(content []byte) ([]byte, error) {
+	var file File
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, err
+	}
+	return json.Marshal(File{Version: 3, Words: normalizeKeys(file.Words)})
+}
+
+// migrateV3ToV4 only changes the version: the files of version 3 don't have any meaning written by the user.
+func migrateV3ToV4(content []byte) ([]byte, error) {
+	var file File
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, err
+	}
+	return json.Marshal(File{Version: 4, Words: file.Words})
+}
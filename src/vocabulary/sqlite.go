@@ -0,0 +1,363 @@
+package vocabulary
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	// Pure Go sqlite driver: it doesn't need cgo, so the application still builds into a single binary.
+	_ "modernc.org/sqlite"
+)
+
+// SQLitePath returns the location of the sqlite database of a language.
+func SQLitePath(language string) string {
+	return fmt.Sprintf("languages/%s/vocabulary.db", language)
+}
+
+/*
+The schema of the database is versioned with the user_version pragma:
+sqliteMigrations[i] upgrades the database from version i to version i+1.
+*/
+
+var sqliteMigrations = []func(b *sqliteBackend, tx *sql.Tx) error{
+	createSchema,
+	normalizeWordKeys,
+	addMeaning,
+}
+
+// createSchema (version 1) creates the tables for the words, the lookups we did and
+// the position we reached in every text, and imports the existing words.json.
+func createSchema(b *sqliteBackend, tx *sql.Tx) error {
+	_, err := tx.Exec(`CREATE TABLE words (
+		word         TEXT PRIMARY KEY,
+		level        INTEGER NOT NULL DEFAULT 0,
+		translation  TEXT NOT NULL DEFAULT '',
+		latinization TEXT NOT NULL DEFAULT '',
+		notes        TEXT NOT NULL DEFAULT '',
+		first_seen   INTEGER,
+		source       TEXT NOT NULL DEFAULT '',
+		context      TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX words_level ON words(level);
+	CREATE TABLE lookups (
+		id   INTEGER PRIMARY KEY AUTOINCREMENT,
+		word TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		at   INTEGER NOT NULL
+	);
+	CREATE INDEX lookups_at ON lookups(at);
+	CREATE TABLE progress (
+		text       TEXT PRIMARY KEY,
+		position   INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);`)
+	if err != nil {
+		return err
+	}
+	return b.importJSON(tx)
+}
+
+// normalizeWordKeys (version 2) stores the words under their normalized key, merging the ones that collide.
+func normalizeWordKeys(b *sqliteBackend, tx *sql.Tx) error {
+	words, err := loadWordsV1(tx)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM words"); err != nil {
+		return err
+	}
+	for word, entry := range normalizeKeys(words) {
+		if err := upsertWordV1(tx, word, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addMeaning (version 3) adds the meaning written by the user to the words.
+func addMeaning(b *sqliteBackend, tx *sql.Tx) error {
+	if _, err := tx.Exec("ALTER TABLE words ADD COLUMN meaning TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if !b.created {
+		return nil
+	}
+	// Version 1 imported words.json without the meanings (there was no column for them yet).
+	words, err := b.readJSON()
+	if err != nil {
+		return err
+	}
+	for word, entry := range normalizeKeys(words) {
+		if entry.Meaning == "" {
+			continue
+		}
+		if _, err := tx.Exec("UPDATE words SET meaning = ? WHERE word = ?", entry.Meaning, word); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+The migrations only use the columns that existed at their version, so they never change once they are
+released: upsertWordV1 and loadWordsV1 read and write the words table as it was at versions 1 and 2
+(upsertWord and loadWords use the current schema).
+*/
+
+// upsertWordV1 writes a single word in the database, with the columns of version 1.
+func upsertWordV1(tx *sql.Tx, word string, entry *Entry) error {
+	var firstSeen sql.NullInt64
+	if entry.FirstSeen != nil {
+		firstSeen = sql.NullInt64{Int64: entry.FirstSeen.Unix(), Valid: true}
+	}
+	_, err := tx.Exec(`INSERT INTO words (word, level, translation, latinization, notes, first_seen, source, context)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(word) DO UPDATE SET level = excluded.level, translation = excluded.translation,
+			latinization = excluded.latinization, notes = excluded.notes, first_seen = excluded.first_seen,
+			source = excluded.source, context = excluded.context`,
+		word, entry.Level, entry.Translation, entry.Latinization, entry.Notes, firstSeen, entry.Source, entry.Context)
+	return err
+}
+
+// loadWordsV1 reads all the words in the database, with the columns of version 1.
+func loadWordsV1(tx *sql.Tx) (map[string]*Entry, error) {
+	rows, err := tx.Query("SELECT word, level, translation, latinization, notes, first_seen, source, context FROM words")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	words := map[string]*Entry{}
+	for rows.Next() {
+		var word string
+		var firstSeen sql.NullInt64
+		entry := &Entry{}
+		if err := rows.Scan(&word, &entry.Level, &entry.Translation, &entry.Latinization, &entry.Notes, &firstSeen, &entry.Source, &entry.Context); err != nil {
+			return nil, err
+		}
+		if firstSeen.Valid {
+			date := time.Unix(firstSeen.Int64, 0)
+			entry.FirstSeen = &date
+		}
+		words[word] = entry
+	}
+	return words, rows.Err()
+}
+
+/*
+sqliteBackend struct:
+the backend that stores the vocabulary in languages/<language>/vocabulary.db.
+Every change only touches the rows of the words that changed, so it scales to
+vocabularies with tens of thousands of words.
+*/
+
+type sqliteBackend struct {
+	language string
+	db       *sql.DB
+	created  bool // Whether the database was created when it was opened (see addMeaning)
+}
+
+/*
+openSQLite function:
+input: language (string)
+output: the sqlite backend of the language, and a possible error.
+The database is created (and migrated to the latest schema) if needed; when it is
+created, the words in the words.json file of the language (if there is one) are imported.
+*/
+
+func openSQLite(language string) (*sqliteBackend, error) {
+	db, err := sql.Open("sqlite", SQLitePath(language))
+	if err != nil {
+		return nil, err
+	}
+	// sqlite only supports one writer at a time.
+	db.SetMaxOpenConns(1)
+	backend := &sqliteBackend{language: language, db: db}
+	if err := backend.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return backend, nil
+}
+
+// migrate brings the schema of the database up to date.
+func (b *sqliteBackend) migrate() error {
+	var version int
+	if err := b.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(sqliteMigrations) {
+		return fmt.Errorf("%s has version %d, but this version of LinGo only supports up to version %d", SQLitePath(b.language), version, len(sqliteMigrations))
+	}
+	b.created = version == 0
+	for ; version < len(sqliteMigrations); version++ {
+		tx, err := b.db.Begin()
+		if err != nil {
+			return err
+		}
+		if err := sqliteMigrations[version](b, tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migrating %s to version %d: %w", SQLitePath(b.language), version+1, err)
+		}
+		// PRAGMA doesn't accept parameters.
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importJSON copies the words of the words.json file of the language into the database (with the columns of version 1).
+func (b *sqliteBackend) importJSON(tx *sql.Tx) error {
+	words, err := b.readJSON()
+	if err != nil {
+		return err
+	}
+	for word, entry := range words {
+		if err := upsertWordV1(tx, word, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readJSON reads the words in the words.json file of the language (there are none if there's no file).
+func (b *sqliteBackend) readJSON() (map[string]*Entry, error) {
+	content, err := os.ReadFile(Path(b.language))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	file, _, err := Migrate(content)
+	if err != nil {
+		return nil, err
+	}
+	return file.Words, nil
+}
+
+// upsertWord writes a single word in the database.
+func upsertWord(tx *sql.Tx, word string, entry *Entry) error {
+	var firstSeen sql.NullInt64
+	if entry.FirstSeen != nil {
+		firstSeen = sql.NullInt64{Int64: entry.FirstSeen.Unix(), Valid: true}
+	}
+	_, err := tx.Exec(`INSERT INTO words (word, level, translation, meaning, latinization, notes, first_seen, source, context)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(word) DO UPDATE SET level = excluded.level, translation = excluded.translation, meaning = excluded.meaning,
+			latinization = excluded.latinization, notes = excluded.notes, first_seen = excluded.first_seen,
+			source = excluded.source, context = excluded.context`,
+		word, entry.Level, entry.Translation, entry.Meaning, entry.Latinization, entry.Notes, firstSeen, entry.Source, entry.Context)
+	return err
+}
+
+func (b *sqliteBackend) Load() (map[string]*Entry, error) {
+	return loadWords(b.db)
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// loadWords reads all the words in the database.
+func loadWords(db querier) (map[string]*Entry, error) {
+	rows, err := db.Query("SELECT word, level, translation, meaning, latinization, notes, first_seen, source, context FROM words")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	words := map[string]*Entry{}
+	for rows.Next() {
+		var word string
+		var firstSeen sql.NullInt64
+		entry := &Entry{}
+		if err := rows.Scan(&word, &entry.Level, &entry.Translation, &entry.Meaning, &entry.Latinization, &entry.Notes, &firstSeen, &entry.Source, &entry.Context); err != nil {
+			return nil, err
+		}
+		if firstSeen.Valid {
+			date := time.Unix(firstSeen.Int64, 0)
+			entry.FirstSeen = &date
+		}
+		words[word] = entry
+	}
+	return words, rows.Err()
+}
+
+// Save only writes the words that changed (or all of them if changed is nil).
+func (b *sqliteBackend) Save(words map[string]*Entry, changed []string) error {
+	if changed == nil {
+		for word := range words {
+			changed = append(changed, word)
+		}
+	}
+	tx, err := b.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, word := range changed {
+		if entry, ok := words[word]; ok {
+			err = upsertWord(tx, word, entry)
+		} else {
+			_, err = tx.Exec("DELETE FROM words WHERE word = ?", word)
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (b *sqliteBackend) Close() error {
+	return b.db.Close()
+}
+
+func (b *sqliteBackend) RecordLookup(word string, kind string, at time.Time) error {
+	_, err := b.db.Exec("INSERT INTO lookups (word, kind, at) VALUES (?, ?, ?)", word, kind, at.Unix())
+	return err
+}
+
+func (b *sqliteBackend) SaveProgress(text string, position int) error {
+	_, err := b.db.Exec(`INSERT INTO progress (text, position, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(text) DO UPDATE SET position = excluded.position, updated_at = excluded.updated_at`,
+		text, position, time.Now().Unix())
+	return err
+}
+
+func (b *sqliteBackend) Progress(text string) (int, error) {
+	var position int
+	err := b.db.QueryRow("SELECT position FROM progress WHERE text = ?", text).Scan(&position)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return position, err
+}
+
+// WordsSeenSince returns the words with a level that we first saw, or looked up, after a date.
+func (b *sqliteBackend) WordsSeenSince(level int, since time.Time) ([]string, error) {
+	rows, err := b.db.Query(`SELECT word FROM words WHERE level = ?
+		AND (first_seen >= ? OR word IN (SELECT word FROM lookups WHERE at >= ?))
+		ORDER BY word`, level, since.Unix(), since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var words []string
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			return nil, err
+		}
+		words = append(words, word)
+	}
+	return words, rows.Err()
+}
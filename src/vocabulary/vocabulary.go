@@ -0,0 +1,330 @@
+/*
+	=====================================================================
+
+** vocabulary package **
+This package is responsible for the storage of the words we encounter in a
+language: for every word it stores the level of knowledge, as well as the
+metadata we collect while studying (translation, latinization, notes, the
+date in which we first saw it, the text we saw it in and the sentence in
+which it appeared).
+The words of a language are stored through a Backend (see backend.go):
+by default in languages/<language>/words.json, using a versioned schema
+(older files are upgraded automatically when they are opened, see
+migrate.go), or in an sqlite database if the "storage" setting in
+setup/config.json is "sqlite" (see sqlite.go).
+
+    =====================================================================
+*/
+
+package vocabulary
+
+/*
+Imported packages:
+1) fmt --> used to format the paths of the files and the errors
+2) sort --> used to sort the results of the queries
+3) sync --> the store is shared with the goroutine that writes it on disk
+4) time --> used to store the date in which we first saw a word
+5) config --> used to know which backend we have to use
+*/
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"example.com/packages/config"
+)
+
+// CurrentVersion is the version of the schema of the words.json files written by this package.
+const CurrentVersion = 4
+
+/*
+Entry struct:
+everything we know about a single word.
+*/
+
+type Entry struct {
+	Level int `json:"level"` // The level of knowledge of the word
+	// level of knowledge 0 --> ignore
+	// 1 --> don't know
+	// 2 --> meh
+	// 3 --> know well
+	Translation  string     `json:"translation,omitempty"`  // The last translation we got for the word
+	Meaning      string     `json:"meaning,omitempty"`      // The meaning written by the user: it wins over every translation
+	Latinization string     `json:"latinization,omitempty"` // The latinization of the word
+	Notes        string     `json:"notes,omitempty"`        // Free-form notes
+	FirstSeen    *time.Time `json:"firstSeen,omitempty"`    // When we first interacted with the word
+	Source       string     `json:"source,omitempty"`       // The text in which we first saw the word
+	Context      string     `json:"context,omitempty"`      // The sentence in which we first saw the word
+}
+
+/*
+File struct:
+it mirrors the structure of the words.json file.
+*/
+
+type File struct {
+	Version int               `json:"version"`
+	Words   map[string]*Entry `json:"words"`
+}
+
+/*
+Store struct:
+the vocabulary of a language, loaded in memory.
+The changes are written on disk in the background by a writer (see writer.go).
+*/
+
+type Store struct {
+	language string
+	backend  Backend
+	loadErr  error // If the vocabulary couldn't be loaded, we refuse to overwrite it.
+	writer   *writer
+
+	mu       sync.Mutex // protects the fields below
+	words    map[string]*Entry
+	pending  map[string]bool // the words changed since the last write
+	writeErr error           // the error of the last write (if any)
+}
+
+// stores caches the opened stores, so that the reader and the menu share the same vocabulary.
+var (
+	stores   = map[string]*Store{}
+	storesMu sync.Mutex
+)
+
+// Path returns the location of the vocabulary file of a language.
+func Path(language string) string {
+	return fmt.Sprintf("languages/%s/words.json", language)
+}
+
+/*
+Open function:
+input: language (string)
+output: the Store of the language, and a possible error.
+
+The backend is chosen according to the "storage" setting in setup/config.json.
+If the vocabulary doesn't exist yet, an empty store is returned.
+If the vocabulary can't be loaded, an empty store is returned alongside the error:
+this store will never overwrite the data on disk.
+*/
+
+func Open(language string) (*Store, error) {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	if store, ok := stores[language]; ok {
+		return store, nil
+	}
+	store := &Store{language: language, words: map[string]*Entry{}, pending: map[string]bool{}}
+
+	var err error
+	if config.Get().Storage == "sqlite" {
+		store.backend, err = openSQLite(language)
+	} else {
+		store.backend = &jsonBackend{language: language}
+	}
+	if err != nil {
+		store.loadErr = err
+		return store, err
+	}
+
+	words, err := store.backend.Load()
+	if err != nil {
+		store.loadErr = err
+		return store, err
+	}
+	store.words = words
+	store.writer = startWriter(store)
+	stores[language] = store
+	return store, nil
+}
+
+/*
+CloseAll function:
+writes the pending changes of all the opened stores and closes their backends.
+It is called when the application quits.
+*/
+
+func CloseAll() error {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	var firstErr error
+	for language, store := range stores {
+		if err := store.writer.stop(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if err := store.backend.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(stores, language)
+	}
+	return firstErr
+}
+
+// Flush writes the pending changes of the store right now, and waits until they are on disk.
+func (s *Store) Flush() error {
+	if s.loadErr != nil {
+		return s.refuse()
+	}
+	return s.writer.flush()
+}
+
+// refuse returns the error we give when we can't save a store that couldn't be loaded.
+func (s *Store) refuse() error {
+	return fmt.Errorf("not saving the vocabulary of %s, it could not be loaded: %w", s.language, s.loadErr)
+}
+
+// Level returns the level of knowledge of a word (0 if we never saw it).
+func (s *Store) Level(word string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.words[word]; ok {
+		return entry.Level
+	}
+	return 0
+}
+
+// Lookup returns a copy of the entry of a word, and whether the word is in the store.
+func (s *Store) Lookup(word string) (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.words[word]; ok {
+		return *entry, true
+	}
+	return Entry{}, false
+}
+
+/*
+Levels method:
+output: a map containing the level of knowledge of every word in the store.
+It is used by the review session and by the dictionary exporters.
+*/
+
+func (s *Store) Levels() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	levels := make(map[string]int, len(s.words))
+	for word, entry := range s.words {
+		levels[word] = entry.Level
+	}
+	return levels
+}
+
+/*
+Update method:
+input: the word we want to change, and a function that changes its entry.
+output: a possible error (the one of the last write on disk, since the writes happen in the background).
+If the word is not in the store yet, a new entry is created (with the current
+date as the first-seen date). The change is then handed to the writer.
+*/
+
+func (s *Store) Update(word string, change func(entry *Entry)) error {
+	if s.loadErr != nil {
+		return s.refuse()
+	}
+	s.mu.Lock()
+	entry, ok := s.words[word]
+	if !ok {
+		now := time.Now()
+		entry = &Entry{FirstSeen: &now}
+		s.words[word] = entry
+	}
+	change(entry)
+	s.pending[word] = true
+	err := s.writeErr
+	s.mu.Unlock()
+
+	s.writer.notify()
+	return err
+}
+
+// SetLevel sets the level of knowledge of a word and saves the store.
+func (s *Store) SetLevel(word string, level int) error {
+	return s.Update(word, func(entry *Entry) {
+		entry.Level = level
+	})
+}
+
+/*
+Encounter method:
+input: a word, the text we are reading and the sentence the word appears in.
+output: a possible error.
+It records where we saw a word for the first time: the first-seen date, the source
+and the context are only filled in if they are still empty (the words migrated from
+the first version of the schema don't have them).
+*/
+
+func (s *Store) Encounter(word string, source string, context string) error {
+	return s.Update(word, func(entry *Entry) {
+		if entry.FirstSeen == nil {
+			now := time.Now()
+			entry.FirstSeen = &now
+		}
+		if entry.Source == "" {
+			entry.Source = source
+		}
+		if entry.Context == "" {
+			entry.Context = context
+		}
+	})
+}
+
+/*
+RecordLookup method:
+input: a word and the kind of lookup we did ("translation", "latinization", "audio" exc.)
+output: a possible error.
+The lookups are only recorded if the backend keeps a history (i.e the sqlite one).
+*/
+
+func (s *Store) RecordLookup(word string, kind string) error {
+	if history, ok := s.backend.(History); ok {
+		return history.RecordLookup(word, kind, time.Now())
+	}
+	return nil
+}
+
+/*
+SaveProgress and Progress methods:
+they store and retrieve the position of the cursor in a text, so that we can
+continue reading from where we left. They only work if the backend keeps a history.
+*/
+
+func (s *Store) SaveProgress(text string, position int) error {
+	if history, ok := s.backend.(History); ok {
+		return history.SaveProgress(text, position)
+	}
+	return nil
+}
+
+func (s *Store) Progress(text string) int {
+	if history, ok := s.backend.(History); ok {
+		position, err := history.Progress(text)
+		if err == nil {
+			return position
+		}
+	}
+	return 0
+}
+
+/*
+WordsSeenSince method:
+input: a level of knowledge and a date.
+output: the words with that level that we saw (or looked up) after the date, sorted alphabetically.
+With the json backend we can only use the date in which we first saw a word.
+*/
+
+func (s *Store) WordsSeenSince(level int, since time.Time) ([]string, error) {
+	if history, ok := s.backend.(History); ok {
+		return history.WordsSeenSince(level, since)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var words []string
+	for word, entry := range s.words {
+		if entry.Level == level && entry.FirstSeen != nil && !entry.FirstSeen.Before(since) {
+			words = append(words, word)
+		}
+	}
+	sort.Strings(words)
+	return words, nil
+}
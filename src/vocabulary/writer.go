@@ -0,0 +1,119 @@
+package vocabulary
+
+import (
+	"time"
+)
+
+// Debounce is how long the writer waits for other changes before writing them on disk:
+// when we mark many words in a row, they are all written at once.
+const Debounce = 500 * time.Millisecond
+
+/*
+writer struct:
+the background goroutine that persists the changes of a store.
+Changing a word only marks it as pending and wakes up the writer, so the
+interface never waits for the disk; the writer then coalesces all the changes
+made within the Debounce interval into a single Save of the backend.
+*/
+
+type writer struct {
+	store   *Store
+	wake    chan struct{}   // signals that there are new pending changes
+	flushes chan chan error // asks the writer to write everything right now
+	quit    chan chan error // asks the writer to write everything and stop
+}
+
+// startWriter creates the writer of a store and starts its goroutine.
+func startWriter(store *Store) *writer {
+	w := &writer{
+		store:   store,
+		wake:    make(chan struct{}, 1),
+		flushes: make(chan chan error),
+		quit:    make(chan chan error),
+	}
+	go w.run()
+	return w
+}
+
+// notify wakes up the writer without ever blocking the caller.
+func (w *writer) notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// flush writes all the pending changes and waits until they are on disk.
+func (w *writer) flush() error {
+	reply := make(chan error)
+	w.flushes <- reply
+	return <-reply
+}
+
+// stop writes all the pending changes and stops the goroutine.
+func (w *writer) stop() error {
+	reply := make(chan error)
+	w.quit <- reply
+	return <-reply
+}
+
+func (w *writer) run() {
+	timer := time.NewTimer(Debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-w.wake:
+			// Wait a little bit more, in case other changes are coming.
+			timer.Reset(Debounce)
+		case <-timer.C:
+			w.write()
+		case reply := <-w.flushes:
+			timer.Stop()
+			reply <- w.write()
+		case reply := <-w.quit:
+			timer.Stop()
+			reply <- w.write()
+			return
+		}
+	}
+}
+
+/*
+write method:
+it takes a snapshot of the pending changes and saves them through the backend.
+If the save fails, the words are marked as pending again, so that they will be
+written with the next change (or when the application quits).
+*/
+
+func (w *writer) write() error {
+	s := w.store
+	s.mu.Lock()
+	if len(s.pending) == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	// We save a copy of the words, so that the interface can keep changing them while we write.
+	snapshot := make(map[string]*Entry, len(s.words))
+	for word, entry := range s.words {
+		copied := *entry
+		snapshot[word] = &copied
+	}
+	changed := make([]string, 0, len(s.pending))
+	for word := range s.pending {
+		changed = append(changed, word)
+	}
+	s.pending = map[string]bool{}
+	s.mu.Unlock()
+
+	err := s.backend.Save(snapshot, changed)
+
+	s.mu.Lock()
+	s.writeErr = err
+	if err != nil {
+		for _, word := range changed {
+			s.pending[word] = true
+		}
+	}
+	s.mu.Unlock()
+	return err
+}